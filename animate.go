@@ -0,0 +1,108 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"time"
+)
+
+// Displays each frame in turn, waiting interval between them, until all frames were shown
+// or ctx is done. Consecutive identical frames are skipped without refreshing the screen.
+// The frames are shown using the current mode, so after InitPartial they replace each other
+// with a partial refresh of the whole screen, and after InitFast with a fast refresh.
+func (e *Epd) PlayFrames(ctx context.Context, frames []image.Image, interval time.Duration) error {
+	var previous []byte
+
+	for i, frame := range frames {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		if err := checkImage(frame); err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+
+		e.mu.Lock()
+		buffer := e.getBuffer(frame, e.threshold)
+		if bytes.Equal(buffer, previous) {
+			e.releaseBuffer(buffer)
+			e.mu.Unlock()
+			continue
+		}
+
+		err := e.withRecovery(func() error {
+			return e.displayFrame(ctx, buffer)
+		})
+		if previous != nil {
+			e.releaseBuffer(previous)
+		}
+		e.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		previous = buffer
+	}
+
+	return nil
+}
+
+// Calls render every interval and displays the frames it returns until ctx is done,
+// e.g. for a clock or a sensor readout. The first frame is rendered right away. Frames
+// identical to what is on screen are skipped, and after InitPartial only the region
+// that changed is refreshed, like DisplayImageDiff. Returns ctx.Err() once ctx is done
+// or the first error displaying a frame.
+func (e *Epd) Run(ctx context.Context, interval time.Duration, render func() image.Image) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.runFrame(ctx, render()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Epd) runFrame(ctx context.Context, frame image.Image) error {
+	if err := checkImage(frame); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buffer := e.getBuffer(frame, e.threshold)
+	defer e.releaseBuffer(buffer)
+	if bytes.Equal(buffer, e.lastBuffer) {
+		return nil
+	}
+
+	return e.withRecovery(func() error {
+		if e.mode == modePartial {
+			return e.displayDiff(ctx, buffer)
+		}
+
+		return e.displayContext(ctx, buffer)
+	})
+}
+
+// Displays a full frame buffer, using a partial refresh when initialized for it.
+func (e *Epd) displayFrame(ctx context.Context, buffer []byte) error {
+	if e.mode == modePartial {
+		return e.sendPartial(ctx, buffer, e.bounds)
+	}
+
+	return e.displayContext(ctx, buffer)
+}
@@ -0,0 +1,65 @@
+package waveshare7in5v2
+
+import "image"
+
+// Queues img to be displayed like DisplayImage and returns right away, so rendering
+// doesn't have to wait for the slow transfer and refresh. Queued images are displayed
+// one at a time in the order they were queued, by a goroutine that exits once the queue
+// is empty. The returned channel receives the result of DisplayImage once img was
+// displayed. img must not be modified until then.
+func (e *Epd) DisplayImageAsync(img image.Image) <-chan error {
+	return e.enqueue(func() error {
+		return e.DisplayImage(img)
+	})
+}
+
+// Blocks until every operation queued before it, e.g. by DisplayImageAsync, has run and
+// the display released the busy line. Close, Shutdown and Sleep don't wait for the queue,
+// so call Sync first to exit cleanly with images still queued. Returns the error of the
+// wait, not those of the queued operations, their channels receive them.
+func (e *Epd) Sync() error {
+	return <-e.enqueue(func() error {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if !e.initialized() {
+			return nil
+		}
+		return e.waitUntilIdle()
+	})
+}
+
+// Adds op to the queue, starting the goroutine running it if needed.
+func (e *Epd) enqueue(op func() error) <-chan error {
+	result := make(chan error, 1)
+
+	e.queueMu.Lock()
+	defer e.queueMu.Unlock()
+
+	e.queue = append(e.queue, func() { result <- op() })
+	if !e.queueRunning {
+		e.queueRunning = true
+		go e.runQueue()
+	}
+
+	return result
+}
+
+// Runs the queued operations until the queue is empty.
+func (e *Epd) runQueue() {
+	for {
+		e.queueMu.Lock()
+		if len(e.queue) == 0 {
+			e.queueRunning = false
+			e.queueMu.Unlock()
+			return
+		}
+
+		op := e.queue[0]
+		e.queue[0] = nil
+		e.queue = e.queue[1:]
+		e.queueMu.Unlock()
+
+		op()
+	}
+}
@@ -0,0 +1,128 @@
+package waveshare7in5v2
+
+import "time"
+
+// Returns how many times the screen was refreshed since the driver was created.
+func (e *Epd) RefreshCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.refreshCount
+}
+
+// Returns how long the last refresh took, from DISPLAY_REFRESH until the display
+// released the busy line. Refreshes getting slower can point to a cold or aging panel.
+func (e *Epd) LastRefreshDuration() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.lastRefresh
+}
+
+// Automatically puts the display to Sleep once it has been idle for d, protecting the
+// panel in always-on installations. The next DisplayImage or Clear transparently wakes
+// it up, see Wake. Passing 0 disables it.
+func (e *Epd) AutoSleep(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.autoSleep = d
+	e.scheduleAutoSleep()
+}
+
+// Restarts the idle countdown after the display was used.
+func (e *Epd) scheduleAutoSleep() {
+	if e.sleepTimer != nil {
+		e.sleepTimer.Stop()
+		e.sleepTimer = nil
+	}
+
+	if e.autoSleep <= 0 {
+		return
+	}
+
+	e.sleepTimer = time.AfterFunc(e.autoSleep, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if e.asleep {
+			return
+		}
+
+		e.debug("Display idle, going to sleep")
+		if err := e.sleep(); err != nil {
+			e.warn("Failed to put display to sleep:", err)
+		}
+	})
+}
+
+// Refreshes the first frame displayed after Init or Wake twice. The display memory is
+// lost in deep sleep, so the panel doesn't know what it is showing and the first image
+// can keep faint ghosts of the previous content. The second refresh cleans them, at the
+// cost of doubling the time of that first frame. Clear needs no flush.
+func WithWakeFlush(enabled bool) Option {
+	return func(e *Epd) error {
+		e.wakeFlush = enabled
+		return nil
+	}
+}
+
+// Brings the display out of Sleep, restoring the register setup of the mode it was
+// last initialized with (Init, InitFast, InitPartial or InitGray4). Does nothing if the
+// display is not asleep. The pairing is Init → Sleep → Wake → Sleep.
+func (e *Epd) Wake() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.wake()
+}
+
+// Re-initializes the display in its previous mode if it was put to sleep, or powers
+// it back on after PowerOff.
+func (e *Epd) wake() error {
+	if e.poweredOff && !e.asleep {
+		return e.powerOn()
+	}
+	if !e.asleep {
+		return nil
+	}
+
+	e.debug("Waking up display")
+	return e.reinit()
+}
+
+// Runs the init sequence of the current mode again, restoring the image memory
+// needed by partial refresh.
+func (e *Epd) reinit() error {
+	switch e.mode {
+	case modeFast:
+		return e.initFast()
+	case modeGray4:
+		return e.initGray4()
+	case modePartial:
+		if err := e.initPartial(); err != nil {
+			return err
+		}
+		return e.restorePlanes()
+	default:
+		return e.initFull()
+	}
+}
+
+// Deep sleep loses the display RAM, partial refresh needs the old plane to match
+// what is on screen so it is reloaded from the last sent frame.
+func (e *Epd) restorePlanes() error {
+	if e.lastBuffer == nil {
+		return nil
+	}
+
+	if err := e.sendCommandWithData(DISPLAY_START_TRANSMISSION_1, e.lastBuffer); err != nil {
+		return err
+	}
+	if err := e.sendCommandWithData(DISPLAY_START_TRANSMISSION_2, e.lastBuffer); err != nil {
+		return err
+	}
+
+	e.frameValid = true
+	return nil
+}
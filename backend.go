@@ -0,0 +1,202 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/stianeikeland/go-rpio/v4"
+)
+
+// The GPIO and SPI operations the driver needs to talk to the display. The default
+// backend uses go-rpio, other GPIO libraries (periph.io, gpiod) or mocks for testing
+// can be used by implementing this interface and passing it with WithBackend.
+//
+// Pins are identified by their BCM number. The chip select pin is driven like any
+// other output pin around each transfer.
+type Backend interface {
+	// Opens the GPIO and SPI devices.
+	Open() error
+	// Releases the SPI device, leaving GPIO usable by the rest of the program.
+	Close() error
+	// Releases the GPIO device.
+	CloseGPIO() error
+
+	// Configures a pin as output.
+	Output(pin int)
+	// Configures a pin as input.
+	Input(pin int)
+	// Drives an output pin high or low.
+	Write(pin int, high bool)
+	// Returns whether an input pin is high.
+	Read(pin int) bool
+
+	// Sets the SPI clock speed in Hz.
+	SetSpeed(hz int)
+	// Sends data over SPI.
+	Transmit(data []byte) error
+}
+
+// Implemented by backends able to read data back from the display. Reads need the
+// display data line to be wired to MISO, which the waveshare HAT doesn't do, so the
+// default backend only implements it with WithMISO.
+type Receiver interface {
+	// Sends n zero bytes while reading n bytes back.
+	Receive(n int) ([]byte, error)
+}
+
+// Implemented by backends able to block until an input pin changes level, e.g. using
+// GPIO interrupts through gpiod, see WithEdgeWait. go-rpio has no interrupt support so
+// the default backend doesn't implement it.
+type EdgeWaiter interface {
+	// Blocks until pin changes level or timeout elapses, returning whether it changed.
+	WaitForEdge(pin int, timeout time.Duration) bool
+}
+
+// Uses a custom Backend instead of go-rpio.
+func WithBackend(b Backend) Option {
+	return func(e *Epd) error {
+		if b == nil {
+			return errors.New("backend must not be nil")
+		}
+
+		e.backend = b
+		return nil
+	}
+}
+
+// The default Backend using go-rpio. go-rpio is process wide, so it is only opened
+// by the first driver and closed by the last one, allowing several panels to be
+// driven from the same program.
+type rpioBackend struct {
+	bus  rpio.SpiDev
+	chip uint8
+}
+
+// Tracks how many drivers use go-rpio and each SPI bus.
+var rpioState struct {
+	sync.Mutex
+
+	users int
+	buses map[rpio.SpiDev]int
+}
+
+func (b *rpioBackend) Open() error {
+	rpioState.Lock()
+	defer rpioState.Unlock()
+
+	if rpioState.users == 0 {
+		if err := rpio.Open(); err != nil {
+			return openError(err)
+		}
+		rpioState.buses = map[rpio.SpiDev]int{}
+	}
+
+	if rpioState.buses[b.bus] == 0 {
+		if err := rpio.SpiBegin(b.bus); err != nil {
+			// Nobody else uses GPIO yet, release it again
+			if rpioState.users == 0 {
+				rpio.Close()
+			}
+			return err
+		}
+	}
+	rpioState.users++
+	rpioState.buses[b.bus]++
+
+	rpio.SpiChipSelect(b.chip)
+	return nil
+}
+
+// Adds a hint to the most common reasons go-rpio fails to open, the original
+// error is still available via errors.Is.
+func openError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("cannot access GPIO, run as root or add the user to the gpio group: %w", err)
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("no GPIO device found, make sure this is running on a Raspberry Pi: %w", err)
+	default:
+		return fmt.Errorf("cannot open GPIO: %w", err)
+	}
+}
+
+func (b *rpioBackend) Close() error {
+	rpioState.Lock()
+	defer rpioState.Unlock()
+
+	if rpioState.buses[b.bus] == 0 {
+		return nil
+	}
+
+	rpioState.buses[b.bus]--
+	if rpioState.buses[b.bus] == 0 {
+		rpio.SpiEnd(b.bus)
+	}
+	return nil
+}
+
+func (b *rpioBackend) CloseGPIO() error {
+	rpioState.Lock()
+	defer rpioState.Unlock()
+
+	if rpioState.users == 0 {
+		return nil
+	}
+
+	rpioState.users--
+	if rpioState.users == 0 {
+		return rpio.Close()
+	}
+	return nil
+}
+
+func (b *rpioBackend) Output(pin int) {
+	rpio.Pin(pin).Output()
+}
+
+func (b *rpioBackend) Input(pin int) {
+	rpio.Pin(pin).Input()
+}
+
+func (b *rpioBackend) Write(pin int, high bool) {
+	if high {
+		rpio.Pin(pin).Write(rpio.High)
+	} else {
+		rpio.Pin(pin).Write(rpio.Low)
+	}
+}
+
+func (b *rpioBackend) Read(pin int) bool {
+	return rpio.Pin(pin).Read() == rpio.High
+}
+
+func (b *rpioBackend) SetSpeed(hz int) {
+	rpio.SpiSpeed(hz)
+}
+
+func (b *rpioBackend) Transmit(data []byte) error {
+	// The chip select is shared by every panel on the bus
+	rpioState.Lock()
+	defer rpioState.Unlock()
+
+	rpio.SpiChipSelect(b.chip)
+	rpio.SpiTransmit(data...)
+	return nil
+}
+
+// The default backend when the display data line is wired to MISO, see WithMISO. Reads
+// on an unwired line return garbage, so only this type implements Receiver.
+type rpioReceiver struct {
+	*rpioBackend
+}
+
+func (b rpioReceiver) Receive(n int) ([]byte, error) {
+	rpioState.Lock()
+	defer rpioState.Unlock()
+
+	rpio.SpiChipSelect(b.chip)
+	return rpio.SpiReceive(n), nil
+}
@@ -0,0 +1,138 @@
+package waveshare7in5v2
+
+import (
+	"context"
+	"errors"
+	"image"
+)
+
+// Partial refreshes collected by Epd.Batch and refreshed together once the function
+// given to Batch returns. Drawing into a Batch doesn't touch the display, updates made
+// directly on the Epd meanwhile are sent right away and aren't part of the batch. A
+// Batch can't be used anymore once its function returned.
+type Batch struct {
+	e       *Epd
+	entries []batchEntry
+	done    bool
+}
+
+// A region drawn into a batch, in native coordinates.
+type batchEntry struct {
+	// The byte aligned region and its pixels
+	r      image.Rectangle
+	buffer []byte
+	// The pixels of r that were drawn, the others are left as they are on screen
+	keep image.Rectangle
+}
+
+// Runs fn with a Batch collecting partial refreshes, refreshing them once fn returns.
+// Overlapping and touching regions are merged so each area is only refreshed once,
+// with the latest content, which saves refreshes when several widgets are updated at
+// the same time. Only the regions drawn into the batch are sent, on top of what is on
+// screen when fn returns. Returns the error of the refreshes, those of the calls made
+// by fn are returned to fn as usual, without anything being sent. Requires InitPartial
+// to be called first, like DisplayPartial.
+func (e *Epd) Batch(fn func(b *Batch)) error {
+	b := &Batch{e: e}
+	fn(b)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b.done = true
+	if len(b.entries) == 0 {
+		return nil
+	}
+
+	return e.withRecovery(func() error {
+		return e.flushBatch(b)
+	})
+}
+
+// Same as Epd.DisplayPartial but draws img into the batch, refreshed once it ends.
+func (b *Batch) DisplayPartial(img image.Image, r image.Rectangle) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e := b.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return b.add(r, func(aligned image.Rectangle) []byte {
+		return e.getRegionBuffer(img, aligned, e.threshold)
+	})
+}
+
+// Same as Epd.ClearRegion but blanks the region in the batch, refreshed once it ends.
+func (b *Batch) ClearRegion(r image.Rectangle) error {
+	e := b.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return b.add(r, e.whiteRegion)
+}
+
+// Adds the region r, in logical coordinates, with the pixels encoded by encode for its
+// byte aligned native region.
+func (b *Batch) add(r image.Rectangle, encode func(aligned image.Rectangle) []byte) error {
+	if b.done {
+		return errors.New("batch already refreshed")
+	}
+
+	e := b.e
+	native := e.rectToNative(r.Intersect(e.logicalBounds()))
+	aligned := e.alignRegion(native)
+	if aligned.Empty() {
+		return errors.New("region is outside of the display bounds")
+	}
+
+	e.debug("Batching partial image")
+	b.entries = append(b.entries, batchEntry{r: aligned, buffer: encode(aligned), keep: native})
+	return nil
+}
+
+// Refreshes the merged regions of b, or the whole frame with a full refresh when it's
+// due, see DisplayPartialPromoted.
+func (e *Epd) flushBatch(b *Batch) error {
+	// The frame on screen now, updates made during the batch included
+	frame := e.whiteBuffer()
+	if e.lastBuffer != nil {
+		copy(frame, e.lastBuffer)
+	}
+
+	regions := make([]image.Rectangle, 0, len(b.entries))
+	for _, entry := range b.entries {
+		e.copyPixels(frame, entry.buffer, entry.r, entry.keep)
+		regions = append(regions, entry.r)
+	}
+
+	regions = mergeRegions(regions, e.bounds)
+	e.debug("Refreshing", len(b.entries), "batched regions as", len(regions))
+
+	if e.promotionDue(len(regions)) {
+		return e.promotePartial(frame, e.bounds)
+	}
+
+	for _, r := range regions {
+		if err := e.sendPartial(context.Background(), e.cropBuffer(frame, r), r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writes the pixels of the byte aligned region buffer r that fall inside of keep into
+// the full frame dst, all in native coordinates.
+func (e *Epd) copyPixels(dst, buffer []byte, r, keep image.Rectangle) {
+	keep = keep.Intersect(r)
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	for y := keep.Min.Y; y < keep.Max.Y; y++ {
+		for x := keep.Min.X; x < keep.Max.X; x++ {
+			mask := byte(0x80 >> (x % PIXEL_SIZE))
+			index := y*e.pixelWidth + x/PIXEL_SIZE
+			dst[index] = dst[index]&^mask | buffer[(y-r.Min.Y)*rowSize+(x-r.Min.X)/PIXEL_SIZE]&mask
+		}
+	}
+}
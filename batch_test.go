@@ -0,0 +1,88 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"testing"
+)
+
+func TestBatchMergesRegions(t *testing.T) {
+	e, fake := newDiffEpd(t)
+	img := diffFrame(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+
+	err := e.Batch(func(b *Batch) {
+		for _, r := range []image.Rectangle{image.Rect(0, 0, 16, 16), image.Rect(8, 8, 32, 24)} {
+			if err := b.DisplayPartial(img, r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(fake.Transfers()) != 0 {
+			t.Error("batched region was sent before the batch ended")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	windows := 0
+	for _, transfer := range fake.Transfers() {
+		if transfer.Command == PARTIAL_WINDOW {
+			windows++
+		}
+	}
+	if windows != 1 {
+		t.Errorf("sent %d windows, want 1", windows)
+	}
+
+	screen := fake.Screen()
+	for _, p := range []image.Point{{0, 0}, {31, 23}} {
+		if c := screen.GrayAt(p.X, p.Y); c.Y != 0 {
+			t.Errorf("batched pixel %v shows %v, want black", p, c)
+		}
+	}
+	if c := screen.GrayAt(0, 20); c.Y != 255 {
+		t.Errorf("pixel outside of the batch shows %v, want white", c)
+	}
+}
+
+func TestBatchKeepsDirectUpdates(t *testing.T) {
+	e, fake := newDiffEpd(t)
+	black := diffFrame(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+
+	err := e.Batch(func(b *Batch) {
+		if err := b.ClearRegion(image.Rect(0, 0, 4, 8)); err != nil {
+			t.Fatal(err)
+		}
+
+		// Sent right away, in the byte the batch refreshes at the end
+		if err := e.DisplayPartial(black, image.Rect(4, 0, 8, 8)); err != nil {
+			t.Fatal(err)
+		}
+		if len(fake.Transfers()) == 0 {
+			t.Error("update outside of the batch was delayed")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	screen := fake.Screen()
+	if c := screen.GrayAt(6, 4); c.Y != 0 {
+		t.Errorf("direct update shows %v after the batch, want black", c)
+	}
+	if c := screen.GrayAt(2, 4); c.Y != 255 {
+		t.Errorf("batched region shows %v, want white", c)
+	}
+}
+
+func TestBatchRejectsUseAfterEnd(t *testing.T) {
+	e, _ := newDiffEpd(t)
+
+	var batch *Batch
+	if err := e.Batch(func(b *Batch) { batch = b }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := batch.ClearRegion(image.Rect(0, 0, 8, 8)); err == nil {
+		t.Error("batch accepted a region after it ended")
+	}
+}
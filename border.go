@@ -0,0 +1,100 @@
+package waveshare7in5v2
+
+import "fmt"
+
+// The color of the border (VBD) around the active area of the panel.
+type BorderColor int
+
+const (
+	// Keeps the border setting of the waveshare examples for the current mode.
+	BorderDefault BorderColor = iota
+	BorderWhite
+	BorderBlack
+	// Leaves the border undriven, which stops it from flickering on refresh.
+	BorderFloating
+)
+
+// Sets the border color. Defaults to BorderDefault.
+func WithBorder(c BorderColor) Option {
+	return func(e *Epd) error {
+		if c < BorderDefault || c > BorderFloating {
+			return fmt.Errorf("unsupported border color %d", c)
+		}
+
+		e.border = c
+		return nil
+	}
+}
+
+// Changes the border color by rewriting the VCOM and data interval setting, without a
+// full re-init. Takes effect on the next refresh. White and black are swapped when the
+// screen is inverted.
+func (e *Epd) SetBorder(c BorderColor) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if c < BorderDefault || c > BorderFloating {
+		return fmt.Errorf("unsupported border color %d", c)
+	}
+
+	e.border = c
+	if e.mode == modeNone || e.asleep {
+		return nil
+	}
+
+	return e.sendVCOMDataInterval(e.mode)
+}
+
+// Returns the VCOM and data interval setting (0x50) for the given mode.
+// The first byte holds the border bits (BDZ, BDV[1:0]), the copy new to old data bit
+// (N2OCP) and the data polarity (DDX[1:0]), the second byte the data interval.
+func (e *Epd) vcomDataInterval(mode initMode) []byte {
+	border := e.border
+	if e.inverted {
+		switch border {
+		case BorderWhite:
+			border = BorderBlack
+		case BorderBlack:
+			border = BorderWhite
+		case BorderDefault:
+			if mode != modePartial {
+				border = BorderBlack
+			}
+		}
+	}
+
+	var bits byte
+	switch border {
+	case BorderWhite:
+		bits = 0x10
+	case BorderBlack:
+		bits = 0x20
+	case BorderFloating:
+		bits = 0x80
+	default:
+		bits = 0x10
+		if mode == modePartial {
+			bits = 0xA0
+		}
+	}
+
+	var setting []byte
+	switch {
+	case e.vcom != nil && mode == modePartial:
+		setting = []byte{bits | 0x09, e.vcom.interval}
+	case e.vcom != nil:
+		setting = []byte{bits, e.vcom.interval}
+	case mode == modePartial:
+		// Copy new data to old data after each refresh
+		setting = []byte{bits | 0x09, 0x07}
+	case mode == modeGray4 || mode == modeFast:
+		setting = []byte{bits, 0x07}
+	default:
+		setting = []byte{bits, 0x17}
+	}
+
+	if e.cdi != 0 {
+		setting[1] = setting[1]&0xF0 | byte(MAX_DATA_INTERVAL-e.cdi)
+	}
+	return setting
+}
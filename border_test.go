@@ -0,0 +1,32 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVCOMDataIntervalPerMode(t *testing.T) {
+	tests := []struct {
+		name string
+		init func(e *Epd) error
+		want []byte
+	}{
+		{"full", (*Epd).Init, []byte{0x10, 0x17}},
+		{"fast", (*Epd).InitFast, []byte{0x10, 0x07}},
+		{"partial", (*Epd).InitPartial, []byte{0xA9, 0x07}},
+		{"gray4", (*Epd).InitGray4, []byte{0x10, 0x07}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, fake := newTestEpd(t)
+			if err := tt.init(e); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := lastTransfer(t, fake, VCOM_DATA_INTERVAL_SETTING); !bytes.Equal(got, tt.want) {
+				t.Errorf("VCOM and data interval % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
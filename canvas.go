@@ -1,52 +1,210 @@
 package waveshare7in5v2
 
 import (
+	"context"
 	"image"
 	"image/color"
 )
 
+// A 1-bit color model that quantizes any color to pure black or white using
+// the default threshold of DisplayImage, DEFAULT_THRESHOLD.
+var MonoModel color.Model = color.ModelFunc(monoModel)
+
+var (
+	monoBlack = color.Gray{Y: 0x00}
+	monoWhite = color.Gray{Y: 0xff}
+)
+
+func monoModel(c color.Color) color.Color {
+	if isBlack(c, DEFAULT_THRESHOLD) {
+		return monoBlack
+	}
+
+	return monoWhite
+}
+
 // The Canvas implements draw.Image and thus allows to use any compatible
-// package to draw on the screen.
+// package to draw on the screen. Pixels are stored directly in the 1-bit
+// buffer format sent to the display. The canvas keeps the rotation, mirroring,
+// threshold and background of the display at the time it is created, so drawing
+// on it never waits for a refresh in progress. Create a new one after changing them.
 type Canvas struct {
 	e *Epd
+	// Geometry and settings of e when the canvas was created
+	view *Epd
 
-	img *image.Gray
+	buffer []byte
+	// Area changed since the last refresh, in native coordinates
+	dirty image.Rectangle
 }
 
+// Creates a blank (white) canvas with the same size as the screen.
 func NewCanvas(e *Epd) *Canvas {
-	img := image.NewGray(e.bounds)
+	return e.NewCanvas()
+}
+
+// Creates a blank (white) canvas with the same size as the screen.
+func (e *Epd) NewCanvas() *Canvas {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
 	c := &Canvas{
 		e: e,
+		view: &Epd{
+			bounds:     e.bounds,
+			pixelWidth: e.pixelWidth,
+			rotation:   e.rotation,
+			mirrorX:    e.mirrorX,
+			mirrorY:    e.mirrorY,
+			threshold:  e.threshold,
+			background: e.background,
+		},
 
-		img: img,
+		buffer: make([]byte, e.bufferSize),
 	}
 
 	return c
 }
 
+// Sends the canvas buffer to the screen as is, without any threshold conversion.
+func (e *Epd) DisplayCanvas(c *Canvas) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayCanvas(c)
+	})
+}
+
+func (e *Epd) displayCanvas(c *Canvas) error {
+	if err := e.display(e.canvasBuffer(c.buffer)); err != nil {
+		return err
+	}
+
+	c.dirty = image.Rectangle{}
+	return nil
+}
+
+// Returns a canvas buffer ready to be sent, taking the image filter and the inversion
+// into account.
+func (e *Epd) canvasBuffer(buffer []byte) []byte {
+	if e.filter != nil {
+		return e.getBuffer(e.decodeBuffer(buffer), e.threshold)
+	}
+
+	if !e.inverted {
+		return buffer
+	}
+
+	inverted := make([]byte, len(buffer))
+	for i, b := range buffer {
+		inverted[i] = ^b
+	}
+
+	return inverted
+}
+
 func (c *Canvas) At(x, y int) color.Color {
-	return c.img.At(x, y)
+	if !(image.Point{x, y}.In(c.Bounds())) {
+		return monoWhite
+	}
+
+	index, mask := c.offset(x, y)
+	if c.buffer[index]&mask != 0 {
+		return monoBlack
+	}
+
+	return monoWhite
 }
 
 func (c *Canvas) Bounds() image.Rectangle {
-	return c.img.Bounds()
+	return c.view.logicalBounds()
 }
 
 func (c *Canvas) ColorModel() color.Model {
-	return c.img.ColorModel()
+	return MonoModel
 }
 
 func (c *Canvas) Set(x, y int, color color.Color) {
-	c.img.Set(x, y, color)
+	if !(image.Point{x, y}.In(c.Bounds())) {
+		return
+	}
+
+	index, mask := c.offset(x, y)
+	if isBlack(flatten(color, c.view.background), c.view.threshold) {
+		c.buffer[index] |= mask
+	} else {
+		c.buffer[index] &^= mask
+	}
+
+	nx, ny := c.view.toNative(x, y)
+	c.dirty = c.dirty.Union(image.Rect(nx, ny, nx+1, ny+1))
+}
+
+// Returns the buffer index and bit mask holding the pixel at x, y.
+func (c *Canvas) offset(x, y int) (int, byte) {
+	x, y = c.view.toNative(x, y)
+	return y*c.view.pixelWidth + x/PIXEL_SIZE, 0x80 >> (x % PIXEL_SIZE)
 }
 
 // Flushes any changes done locally and updates the display
-func (c *Canvas) Refresh() {
-	c.e.DisplayImage(c.img)
+func (c *Canvas) Refresh() error {
+	return c.e.DisplayCanvas(c)
+}
+
+// Updates the display with the pixels set since the last refresh. Only their bounding
+// box is sent with a partial refresh when the display was initialized with InitPartial
+// and it covers at most DIFF_FULL_REFRESH_RATIO of the screen, otherwise the whole
+// canvas is refreshed. Does nothing if no pixel was set.
+func (c *Canvas) Flush() error {
+	c.e.mu.Lock()
+	defer c.e.mu.Unlock()
+
+	if c.dirty.Empty() {
+		return nil
+	}
+
+	r := c.e.alignRegion(c.dirty)
+	return c.e.withRecovery(func() error {
+		if c.e.mode != modePartial || float64(r.Dx()*r.Dy()) > DIFF_FULL_REFRESH_RATIO*float64(c.e.bounds.Dx()*c.e.bounds.Dy()) {
+			return c.e.displayCanvas(c)
+		}
+
+		return c.flushPartial(r)
+	})
+}
+
+// Same as Flush but always uses a partial refresh, InitPartial must be called first.
+func (c *Canvas) FlushPartial() error {
+	c.e.mu.Lock()
+	defer c.e.mu.Unlock()
+
+	if c.dirty.Empty() {
+		return nil
+	}
+
+	return c.e.withRecovery(func() error {
+		return c.flushPartial(c.e.alignRegion(c.dirty))
+	})
+}
+
+func (c *Canvas) flushPartial(r image.Rectangle) error {
+	// The filter may look at the neighbours of the region, so crop afterwards
+	buffer := c.e.cropBuffer(c.e.canvasBuffer(c.buffer), r)
+	if err := c.e.sendPartial(context.Background(), buffer, r); err != nil {
+		return err
+	}
+
+	c.dirty = image.Rectangle{}
+	return nil
 }
 
 // Clear the buffer and updates the screen right away.
-func (c *Canvas) Clear() {
-	c.e.Clear()
+func (c *Canvas) Clear() error {
+	for i := range c.buffer {
+		c.buffer[i] = 0x00
+	}
+	c.dirty = image.Rectangle{}
+
+	return c.e.Clear()
 }
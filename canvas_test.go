@@ -0,0 +1,32 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+	"time"
+)
+
+func TestCanvasDrawsWithoutLockingDisplay(t *testing.T) {
+	e, _ := newTestEpd(t)
+	c := e.NewCanvas()
+
+	// As during a refresh
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		draw.Draw(c, image.Rect(0, 0, 16, 16), image.Black, image.Point{}, draw.Src)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drawing on the canvas waited for the display")
+	}
+	if c.At(8, 8) != monoBlack {
+		t.Error("drawn pixel isn't black")
+	}
+}
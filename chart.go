@@ -0,0 +1,216 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// How DisplayChart draws a series.
+type ChartKind int
+
+const (
+	// Joins the values with a line.
+	ChartLine ChartKind = iota
+	// Draws a bar for each value, from zero or the bottom of the chart.
+	ChartBar
+)
+
+// How DisplayChart and RenderChart draw a chart. The zero value gives a line chart
+// covering the whole screen and scaled to the values.
+type ChartOptions struct {
+	Kind ChartKind
+	// Area of the screen the chart covers, empty for the whole screen.
+	Region image.Rectangle
+	// Range of values between the bottom and the top of the chart. When both are 0
+	// the range of the series is used.
+	Min, Max float64
+	// Writes the top and bottom values of the range along the vertical axis.
+	Labels bool
+}
+
+// Draws series as a black on white chart in the region of opts and displays it, e.g.
+// the temperature of the last hours on a dashboard. The rest of the screen keeps the
+// last frame, and after InitPartial and a first frame only the region is refreshed, so
+// charts can live alongside other widgets. NaN values leave a gap.
+func (e *Epd) DisplayChart(series []float64, opts ChartOptions) error {
+	if len(series) == 0 {
+		return errors.New("chart series must not be empty")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	screen := e.logicalBounds()
+	r := screen
+	if !opts.Region.Empty() {
+		r = opts.Region.Intersect(screen)
+		if r.Empty() {
+			return errors.New("region is outside of the display bounds")
+		}
+	}
+
+	frame := image.NewGray(screen)
+	chart := RenderChart(series, r.Size(), opts)
+	draw.Draw(frame, r, chart, image.Point{}, draw.Src)
+
+	return e.withRecovery(func() error {
+		return e.displayOver(frame, r)
+	})
+}
+
+// Renders series as a chart of the given size, e.g. to Composite it with other layers.
+// The Region of opts is ignored.
+func RenderChart(series []float64, size image.Point, opts ChartOptions) *image.Gray {
+	img := image.NewGray(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	if len(series) == 0 || size.X < 2 || size.Y < 2 {
+		return img
+	}
+
+	lo, hi := opts.Min, opts.Max
+	if lo == 0 && hi == 0 {
+		lo, hi = seriesRange(series)
+	}
+	if hi <= lo {
+		// A flat series sits in the middle
+		lo, hi = lo-1, lo+1
+	}
+
+	plot := img.Bounds()
+	if opts.Labels {
+		plot.Min.X += drawChartLabels(img, lo, hi)
+	}
+
+	// Axes along the left and bottom edges
+	draw.Draw(img, image.Rect(plot.Min.X, plot.Min.Y, plot.Min.X+1, plot.Max.Y), image.Black, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(plot.Min.X, plot.Max.Y-1, plot.Max.X, plot.Max.Y), image.Black, image.Point{}, draw.Src)
+	plot.Min.X += 2
+	plot.Max.Y -= 2
+	if plot.Dx() < 1 || plot.Dy() < 1 {
+		return img
+	}
+
+	yOf := func(v float64) int {
+		v = math.Max(lo, math.Min(hi, v))
+		return plot.Max.Y - 1 - int(math.Round((v-lo)/(hi-lo)*float64(plot.Dy()-1)))
+	}
+
+	switch opts.Kind {
+	case ChartBar:
+		base := yOf(math.Max(lo, math.Min(hi, 0)))
+		width := float64(plot.Dx()) / float64(len(series))
+		for i, v := range series {
+			if math.IsNaN(v) {
+				continue
+			}
+
+			x0 := plot.Min.X + int(float64(i)*width)
+			x1 := plot.Min.X + int(float64(i+1)*width)
+			if x1-x0 > 2 {
+				// Keeps neighbouring bars apart
+				x1--
+			}
+
+			y0, y1 := yOf(v), base
+			if y0 > y1 {
+				y0, y1 = y1, y0
+			}
+			draw.Draw(img, image.Rect(x0, y0, x1, y1+1), image.Black, image.Point{}, draw.Src)
+		}
+	default:
+		xOf := func(i int) int {
+			if len(series) == 1 {
+				return plot.Min.X + plot.Dx()/2
+			}
+			return plot.Min.X + i*(plot.Dx()-1)/(len(series)-1)
+		}
+
+		for i, v := range series {
+			if math.IsNaN(v) {
+				continue
+			}
+
+			if i > 0 && !math.IsNaN(series[i-1]) {
+				drawLine(img, image.Pt(xOf(i-1), yOf(series[i-1])), image.Pt(xOf(i), yOf(v)))
+			} else {
+				img.SetGray(xOf(i), yOf(v), color.Gray{})
+			}
+		}
+	}
+
+	return img
+}
+
+// Returns the smallest and largest values of series, ignoring NaNs.
+func seriesRange(series []float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range series {
+		if math.IsNaN(v) {
+			continue
+		}
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+
+	if math.IsInf(lo, 1) {
+		return 0, 0
+	}
+	return lo, hi
+}
+
+// Writes hi at the top left of img and lo at the bottom left, returning the width
+// they take.
+func drawChartLabels(img draw.Image, lo, hi float64) int {
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.Black,
+		Face: face,
+	}
+
+	top := strconv.FormatFloat(hi, 'g', 4, 64)
+	bottom := strconv.FormatFloat(lo, 'g', 4, 64)
+	width := drawer.MeasureString(top)
+	if w := drawer.MeasureString(bottom); w > width {
+		width = w
+	}
+
+	metrics := face.Metrics()
+	bounds := img.Bounds()
+	drawer.Dot = fixed.Point26_6{X: fixed.I(bounds.Min.X), Y: fixed.I(bounds.Min.Y) + metrics.Ascent}
+	drawer.DrawString(top)
+	drawer.Dot = fixed.Point26_6{X: fixed.I(bounds.Min.X), Y: fixed.I(bounds.Max.Y) - metrics.Descent}
+	drawer.DrawString(bottom)
+
+	return width.Ceil() + 4
+}
+
+// Draws a one pixel wide black line from a to b, both included.
+func drawLine(img *image.Gray, a, b image.Point) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	steps := dx
+	if steps < 0 {
+		steps = -steps
+	}
+	if dy > steps {
+		steps = dy
+	} else if -dy > steps {
+		steps = -dy
+	}
+
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		img.SetGray(a.X+int(math.Round(float64(dx)*t)), a.Y+int(math.Round(float64(dy)*t)), color.Gray{})
+	}
+}
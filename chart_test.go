@@ -0,0 +1,30 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+func TestDisplayChartKeepsInvertedFrame(t *testing.T) {
+	e, fake := newTestEpd(t, WithInverted(true))
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	white := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+	if err := e.DisplayImage(white); err != nil {
+		t.Fatal(err)
+	}
+	want := fake.Screen().GrayAt(0, 0)
+
+	opts := ChartOptions{Region: image.Rect(400, 200, 600, 300)}
+	for i := 0; i < 2; i++ {
+		if err := e.DisplayChart([]float64{1, 3, 2}, opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := fake.Screen().GrayAt(0, 0); got != want {
+			t.Errorf("update %d changed the rest of the screen to %v, want %v", i, got, want)
+		}
+	}
+}
@@ -1,36 +1,194 @@
 package waveshare7in5v2
 
 import (
-	"github.com/stianeikeland/go-rpio/v4"
+	"context"
+	"time"
 )
 
-func (e *Epd) sendCommand(cmd byte) {
-	e.dc.Write(rpio.Low)
-	e.cs.Write(rpio.Low)
+// Drives the CS pin around a transfer when using ChipSelectManual, the line is active low.
+// With a Demux the address of the panel is set first, and the demux is held until the
+// chip is deselected.
+func (e *Epd) selectChip(selected bool) {
+	if e.demux != nil && selected {
+		e.demux.selectPanel(e.backend, e.demuxIndex)
+	}
+
+	if e.csMode == ChipSelectManual {
+		e.backend.Write(e.cs, !selected)
+	}
+
+	if e.demux != nil && !selected {
+		e.demux.release()
+	}
+}
+
+func (e *Epd) sendCommand(cmd byte) error {
+	if e.recording {
+		e.initSequence = append(e.initSequence, []byte{cmd})
+	}
+
+	e.backend.Write(e.dc, false)
+	e.selectChip(true)
+
+	err := e.transmit([]byte{cmd})
+
+	e.selectChip(false)
+	return err
+}
+
+func (e *Epd) sendData(data []byte) error {
+	if e.recording && len(e.initSequence) > 0 {
+		last := len(e.initSequence) - 1
+		e.initSequence[last] = append(e.initSequence[last], data...)
+	}
+
+	e.backend.Write(e.dc, true)
+	e.selectChip(true)
+
+	var err error
+	sent := 0
+	for _, chunk := range splitInChunks(data, e.chunkSize) {
+		if err = e.transmit(chunk); err != nil {
+			break
+		}
+
+		sent += len(chunk)
+		if e.onProgress != nil {
+			e.onProgress(sent, len(data))
+		}
+	}
+
+	e.selectChip(false)
+	return err
+}
+
+// Sends data over SPI, retrying up to spiRetries times with an increasing delay.
+func (e *Epd) transmit(data []byte) error {
+	err := e.backend.Transmit(data)
+	for attempt := 1; err != nil && attempt <= e.spiRetries; attempt++ {
+		e.warn("SPI transfer failed, retrying:", err)
+		e.wait(time.Duration(attempt) * SPI_RETRY_BACKOFF)
+		err = e.backend.Transmit(data)
+	}
+
+	return err
+}
 
-	rpio.SpiTransmit(cmd)
+// Returns ErrReadNotSupported if the backend can't read from the display, see WithMISO.
+func (e *Epd) checkReadable() error {
+	if _, ok := e.backend.(Receiver); !ok {
+		return ErrReadNotSupported
+	}
+
+	return nil
+}
+
+// Reads n bytes sent by the display after a command.
+func (e *Epd) receiveData(n int) ([]byte, error) {
+	receiver, ok := e.backend.(Receiver)
+	if !ok {
+		return nil, ErrReadNotSupported
+	}
+
+	e.backend.Write(e.dc, true)
+	e.selectChip(true)
+
+	data, err := receiver.Receive(n)
+
+	e.selectChip(false)
+	return data, err
+}
+
+func (e *Epd) sendCommandWithData(cmd byte, data []byte) error {
+	if err := e.sendCommand(cmd); err != nil {
+		return err
+	}
+
+	return e.sendData(data)
+}
 
-	e.cs.Write(rpio.High)
+func (e *Epd) waitUntilIdle() error {
+	return e.waitUntilIdleContext(context.Background())
 }
 
-func (e *Epd) sendData(data []byte) {
-	e.dc.Write(rpio.High)
-	e.cs.Write(rpio.Low)
+// Same as waitUntilIdle but stops polling and returns ctx.Err() once ctx is done.
+func (e *Epd) waitUntilIdleContext(ctx context.Context) error {
+	start := time.Now()
+	defer func() { e.busyStats.record(time.Since(start)) }()
+	deadline := start.Add(e.busyTimeout)
+
+	waiter, edges := e.backend.(EdgeWaiter)
+	edges = edges && e.edgeWait
+
+	for e.isBusy() {
+		if time.Now().After(deadline) {
+			return ErrBusyTimeout
+		}
 
-	for _, chunk := range splitInChunks(data) {
-		rpio.SpiTransmit(chunk...)
+		if edges {
+			// Returns as soon as the busy line is released, ctx is checked at least
+			// every poll interval
+			waiter.WaitForEdge(e.busy, e.pollInterval)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Through the sleep function so tests scripting busy states don't wait, ctx is
+		// checked every poll interval
+		e.wait(e.pollInterval)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Catches the busy line being released shortly after the poll
+		for i := 0; i < e.rereadMax && e.isBusy(); i++ {
+			e.wait(e.rereadDelay)
+		}
 	}
 
-	e.cs.Write(rpio.High)
+	return nil
 }
 
-func (e *Epd) sendCommandWithData(cmd byte, data []byte) {
-	e.sendCommand(cmd)
-	e.sendData(data)
+// How long the driver waited for the display to release the busy line, see
+// BusyWaitStats. Waits include the refreshes, so they usually last a few seconds.
+type BusyWaitStats struct {
+	// Number of waits
+	Count int
+	// Sum, longest and last of their durations
+	Total   time.Duration
+	Longest time.Duration
+	Last    time.Duration
 }
 
-func (e *Epd) waitUntilIdle() {
-	for e.busy.Read() == rpio.Low {
-		wait(100)
+func (s *BusyWaitStats) record(d time.Duration) {
+	s.Count++
+	s.Total += d
+	s.Last = d
+	if d > s.Longest {
+		s.Longest = d
 	}
 }
+
+// Returns how long the waits for the busy line took since the driver was created.
+// A Longest close to the busy timeout hints at the busy line being released without
+// the driver noticing, see WithBusyReread.
+func (e *Epd) BusyWaitStats() BusyWaitStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.busyStats
+}
+
+// Reports whether the display is currently busy, e.g. refreshing, without waiting.
+// It reads the busy line directly so it doesn't block while another goroutine is
+// updating the display.
+func (e *Epd) IsBusy() bool {
+	return e.isBusy()
+}
+
+func (e *Epd) isBusy() bool {
+	// The V2 holds the busy line low while the display is busy
+	return e.backend.Read(e.busy) == e.busyActiveHigh
+}
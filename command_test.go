@@ -0,0 +1,31 @@
+package waveshare7in5v2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusyPollingUsesSleepFunc(t *testing.T) {
+	var slept time.Duration
+	e, fake := newTestEpd(t,
+		WithBusyPollInterval(time.Second),
+		WithSleepFunc(func(d time.Duration) { slept += d }),
+	)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ScriptBusy(true, true, true)
+	slept = 0
+	start := time.Now()
+	if err := e.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("waiting for the busy line took %v in real time", elapsed)
+	}
+	if slept < time.Second {
+		t.Errorf("slept %v through the sleep function, want at least a poll interval", slept)
+	}
+}
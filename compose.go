@@ -0,0 +1,43 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+)
+
+// An image drawn with its top left corner at Point, see Composite.
+type Layer struct {
+	Image image.Image
+	Point image.Point
+}
+
+// Returns the area of the screen covered by the layer, e.g. to only update the
+// layer that changed with DisplayPartial.
+func (l Layer) Rect() image.Rectangle {
+	src := l.Image.Bounds()
+	return src.Sub(src.Min).Add(l.Point)
+}
+
+// Displays img with its top left corner at pt, on an otherwise white screen. Any part
+// of the image falling outside of the screen is clipped. Useful to show an icon, a logo
+// or a QR code without padding it to the screen size first.
+func (e *Epd) DisplayImageAt(img image.Image, pt image.Point) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	return e.DisplayImage(e.Composite([]Layer{{Image: img, Point: pt}}))
+}
+
+// Draws layers in order onto a white image with the size of the screen, later layers
+// covering earlier ones. The result is ready to be passed to DisplayImage.
+func (e *Epd) Composite(layers []Layer) *image.Gray {
+	frame := image.NewGray(e.Bounds())
+	draw.Draw(frame, frame.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, l := range layers {
+		draw.Draw(frame, l.Rect(), l.Image, l.Image.Bounds().Min, draw.Over)
+	}
+
+	return frame
+}
@@ -0,0 +1,67 @@
+package waveshare7in5v2
+
+import "fmt"
+
+// Flashes the whole screen black then white cycles times to condition the particles
+// before showing content, which reduces ghosting after a cold start or a long idle
+// period. 1 or 2 cycles are enough at room temperature, use 3 to 5 below 10°C, e.g.
+// outdoors or in a fridge. Each cycle takes two full refreshes, so call it once at
+// startup rather than before every update. Init must be called first, the refreshes
+// use the waveform of the current mode and the screen is left white.
+func (e *Epd) Condition(cycles int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if cycles < 0 {
+		return fmt.Errorf("conditioning cycles %d must not be negative", cycles)
+	}
+
+	return e.condition(cycles)
+}
+
+func (e *Epd) condition(cycles int) error {
+	e.debug("Conditioning display")
+	for i := 0; i < cycles; i++ {
+		for _, black := range []bool{true, false} {
+			if err := e.withRecovery(func() error { return e.clear(black) }); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.debug("Display conditioned")
+	return nil
+}
+
+// Runs cycles conditioning cycles of Condition, two full refreshes each, automatically
+// during the first full init by Init, InitIfNeeded, InitRobust, SetMode(ModeFull) or
+// Display, so the first image shown after a cold boot is already clean. 1 or 2 cycles
+// are usually enough, see Condition for colder environments. Later inits don't repeat
+// them. Defaults to 0, no warm-up.
+func WithWarmupCycles(cycles int) Option {
+	return func(e *Epd) error {
+		if cycles < 0 {
+			return fmt.Errorf("warm-up cycles %d must not be negative", cycles)
+		}
+
+		e.warmup = cycles
+		return nil
+	}
+}
+
+// Initializes the display in full refresh mode, running the warm-up cycles of
+// WithWarmupCycles after the first init.
+func (e *Epd) initFullWarm() error {
+	if err := e.initFull(); err != nil {
+		return err
+	}
+
+	if e.warmup > 0 && !e.warmedUp {
+		if err := e.condition(e.warmup); err != nil {
+			return err
+		}
+		e.warmedUp = true
+	}
+
+	return nil
+}
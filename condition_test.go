@@ -0,0 +1,33 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWarmupCyclesRunOnFirstFullInit(t *testing.T) {
+	for name, init := range map[string]func(e *Epd) error{
+		"Init":         func(e *Epd) error { return e.Init() },
+		"InitIfNeeded": func(e *Epd) error { return e.InitIfNeeded() },
+		"InitRobust":   func(e *Epd) error { return e.InitRobust() },
+		"SetMode":      func(e *Epd) error { return e.SetMode(ModeFull) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			e, fake := newTestEpd(t, WithWarmupCycles(2))
+			if err := init(e); err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.Count(fake.Commands(), []byte{DISPLAY_REFRESH}); got != 4 {
+				t.Errorf("warm-up did %d refreshes, want 4", got)
+			}
+
+			fake.ClearTransfers()
+			if err := e.Init(); err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.Count(fake.Commands(), []byte{DISPLAY_REFRESH}); got != 0 {
+				t.Errorf("second init did %d refreshes, want 0", got)
+			}
+		})
+	}
+}
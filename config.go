@@ -0,0 +1,30 @@
+package waveshare7in5v2
+
+// The geometry of the display, useful to pre-allocate and validate buffers.
+type Config struct {
+	// Native size of the panel in pixels, not affected by the rotation.
+	Width  int
+	Height int
+
+	// Size in bytes of a full frame buffer, 1 bit per pixel.
+	BufferSize int
+	// Number of bytes per row in a frame buffer.
+	PixelWidth int
+
+	// Current rotation in degrees, see SetRotation.
+	Rotation int
+}
+
+// Returns the geometry of the display.
+func (e *Epd) Config() Config {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return Config{
+		Width:      e.bounds.Dx(),
+		Height:     e.bounds.Dy(),
+		BufferSize: e.bufferSize,
+		PixelWidth: e.pixelWidth,
+		Rotation:   e.rotation,
+	}
+}
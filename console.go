@@ -0,0 +1,132 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// A scrolling text console, e.g. for a status board showing the latest events. Lines
+// are printed top to bottom and once the screen is full the oldest ones scroll off
+// the top. A Console must not be used from several goroutines at once.
+type Console struct {
+	canvas *Canvas
+	face   font.Face
+
+	lines    []string
+	maxLines int
+}
+
+// Creates a console printing with face onto a blank screen, a monospace face such as
+// basicfont.Face7x13 works best. The screen is only updated by Println.
+func (e *Epd) NewConsole(face font.Face) *Console {
+	c := &Console{
+		canvas: e.NewCanvas(),
+		face:   face,
+	}
+
+	if height := face.Metrics().Height.Ceil(); height > 0 {
+		c.maxLines = c.canvas.Bounds().Dy() / height
+	}
+
+	return c
+}
+
+// Prints s on a new line and updates the screen. Lines wider than the screen are
+// wrapped, at spaces when possible, and each "\n" starts a new line. Only the new
+// lines are sent while the screen isn't full, and after InitPartial and SetBaseImage
+// every update is a partial refresh so the display doesn't flash when it scrolls.
+func (c *Console) Println(s string) error {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		lines = append(lines, c.wrap(line)...)
+	}
+
+	first := len(c.lines)
+	c.lines = append(c.lines, lines...)
+	if overflow := len(c.lines) - c.maxLines; overflow > 0 {
+		// Everything moves up, redraw the whole console
+		c.lines = append(c.lines[:0], c.lines[overflow:]...)
+		first = 0
+	}
+
+	c.draw(first)
+
+	c.canvas.e.mu.Lock()
+	partial := c.canvas.e.mode == modePartial
+	c.canvas.e.mu.Unlock()
+
+	if partial {
+		return c.canvas.FlushPartial()
+	}
+	return c.canvas.Flush()
+}
+
+// Removes every line and blanks the screen.
+func (c *Console) Clear() error {
+	c.lines = nil
+	return c.canvas.Clear()
+}
+
+// Draws the lines from index first onwards, blanking the rows they cover first. The
+// rest of the screen isn't touched so only those rows are sent.
+func (c *Console) draw(first int) {
+	height := c.face.Metrics().Height.Ceil()
+	bounds := c.canvas.Bounds()
+
+	rows := image.Rect(bounds.Min.X, bounds.Min.Y+first*height, bounds.Max.X, bounds.Min.Y+len(c.lines)*height)
+	draw.Draw(c.canvas, rows.Intersect(bounds), image.White, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  c.canvas,
+		Src:  image.Black,
+		Face: c.face,
+	}
+	ascent := c.face.Metrics().Ascent
+	for i := first; i < len(c.lines); i++ {
+		drawer.Dot = fixed.Point26_6{
+			X: fixed.I(bounds.Min.X),
+			Y: fixed.I(bounds.Min.Y+i*height) + ascent,
+		}
+		drawer.DrawString(c.lines[i])
+	}
+}
+
+// Splits line into lines that fit the width of the screen.
+func (c *Console) wrap(line string) []string {
+	width := fixed.I(c.canvas.Bounds().Dx())
+
+	var lines []string
+	for font.MeasureString(c.face, line) > width {
+		cut := c.fitting(line, width)
+		// Prefer breaking at the last space that fits
+		if i := strings.LastIndexByte(line[:cut], ' '); i > 0 {
+			lines = append(lines, line[:i])
+			line = line[i+1:]
+			continue
+		}
+
+		lines = append(lines, line[:cut])
+		line = line[cut:]
+	}
+
+	return append(lines, line)
+}
+
+// Returns the length in bytes of the longest prefix of s fitting in width, at least
+// one rune so wrapping always makes progress.
+func (c *Console) fitting(s string, width fixed.Int26_6) int {
+	var advance fixed.Int26_6
+	for i, r := range s {
+		a, _ := c.face.GlyphAdvance(r)
+		if i > 0 && advance+a > width {
+			return i
+		}
+		advance += a
+	}
+
+	return len(s)
+}
@@ -0,0 +1,35 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestConsoleSendsOnlyNewLines(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.InitPartial(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetBaseImage(image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))); err != nil {
+		t.Fatal(err)
+	}
+
+	c := e.NewConsole(basicfont.Face7x13)
+	for i, line := range []string{"first", "second"} {
+		fake.ClearTransfers()
+		if err := c.Println(line); err != nil {
+			t.Fatal(err)
+		}
+
+		window := lastTransfer(t, fake, PARTIAL_WINDOW)
+		top := int(window[4])<<8 | int(window[5])
+		bottom := int(window[6])<<8 | int(window[7]) + 1
+
+		height := basicfont.Face7x13.Metrics().Height.Ceil()
+		if top < i*height || bottom > (i+1)*height {
+			t.Errorf("line %d sent rows %d to %d, want within %d to %d", i, top, bottom, i*height, (i+1)*height)
+		}
+	}
+}
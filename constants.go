@@ -1,5 +1,7 @@
 package waveshare7in5v2
 
+import "time"
+
 const (
 	EPD_WIDTH  int = 800
 	EPD_HEIGHT int = 480
@@ -16,13 +18,85 @@ const (
 	DISPLAY_REFRESH              byte = 0x12
 	DISPLAY_START_TRANSMISSION_2 byte = 0x13
 	DUAL_SPI                     byte = 0x15
+	LUT_VCOM                     byte = 0x20
+	LUT_WW                       byte = 0x21
+	LUT_BW                       byte = 0x22
+	LUT_WB                       byte = 0x23
+	LUT_BB                       byte = 0x24
+	TEMPERATURE_CALIBRATION      byte = 0x40
 	VCOM_DATA_INTERVAL_SETTING   byte = 0x50
 	TCON                         byte = 0x60
 	RESOLUTION_SETTING           byte = 0x61
 	GET_STATUS                   byte = 0x71
 	VCOM_DC                      byte = 0x82
+	PARTIAL_WINDOW               byte = 0x90
+	PARTIAL_IN                   byte = 0x91
+	PARTIAL_OUT                  byte = 0x92
+	CASCADE_SETTING              byte = 0xE0
+	FORCE_TEMPERATURE            byte = 0xE5
 )
 
+// Default size of each SPI transfer, matching the default spidev.bufsiz of the kernel.
 const MAX_CHUNK_SIZE = 4096
 
 const PIXEL_SIZE = 8
+
+// Number of bytes holding a row of pixels, the last byte is padded if EPD_WIDTH isn't
+// a multiple of PIXEL_SIZE.
+const ROW_SIZE = (EPD_WIDTH + PIXEL_SIZE - 1) / PIXEL_SIZE
+
+// Fails to compile unless PIXEL_SIZE is 8, the buffers are packed 1 bit per pixel
+// into bytes through masks like 0x80 >> px.
+var _ [PIXEL_SIZE - 8]struct{}
+var _ [8 - PIXEL_SIZE]struct{}
+
+// Pixel density of the panel, 800 pixels over its 163.2mm wide active area.
+const PANEL_DPI = 124.5
+
+// Luminance, from 0 to 255, below which pixels are displayed as black.
+const DEFAULT_THRESHOLD uint8 = 199
+
+// Size in bytes of each lookup table, 7 groups of 6 bytes.
+const LUT_SIZE = 42
+
+// SPI clock speed used by the waveshare examples, in Hz. The datasheet specifies a
+// minimum serial clock cycle of 100ns for writes, so anything above MAX_SPI_SPEED
+// is out of spec and can corrupt transfers.
+const (
+	DEFAULT_SPI_SPEED = 4000000
+	MAX_SPI_SPEED     = 10000000
+)
+
+// How long to wait for the display to release the busy line before giving up, and
+// how often the busy line is checked in the meantime.
+const (
+	BUSY_TIMEOUT       = 10 * time.Second
+	BUSY_POLL_INTERVAL = 100 * time.Millisecond
+)
+
+// How long Sleep waits after entering deep sleep, as done by the waveshare examples.
+const SLEEP_DELAY = 2 * time.Second
+
+// Delay before retrying a failed SPI transfer, multiplied by the attempt number.
+const SPI_RETRY_BACKOFF = 10 * time.Millisecond
+
+// How long the reset line is pulled low, and how long to wait before and after the
+// pulse, as done by the waveshare examples. The controller needs a pulse of at least
+// 50µs to register the reset.
+const (
+	RESET_PULSE  = 2 * time.Millisecond
+	RESET_SETTLE = 20 * time.Millisecond
+)
+
+// How many more times the init sequences reset the display and try again when it
+// doesn't power on, see WithInitRetries.
+const DEFAULT_INIT_RETRIES = 2
+
+// Default BCM pin numbers, matching the wiring of the waveshare e-Paper HAT
+// and the DEV_Config.h of the official C examples.
+const (
+	DEFAULT_RST_PIN  = 17
+	DEFAULT_DC_PIN   = 25
+	DEFAULT_CS_PIN   = 8
+	DEFAULT_BUSY_PIN = 24
+)
@@ -0,0 +1,31 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/color"
+)
+
+// Returns the smallest rectangle holding every pixel of img with a luminance below
+// threshold, i.e. the pixels displayed as black, in the coordinates of img. It is empty
+// when img would be displayed all white. Passing it to DisplayPartial only refreshes
+// where there is content, which is much faster for mostly white images. Transparent
+// pixels are considered white, and img must have finite bounds.
+func BoundingBox(img image.Image, threshold uint8) image.Rectangle {
+	e := &Epd{background: color.White}
+	luminanceAt := e.luminanceFunc(img)
+
+	box := image.Rectangle{}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if luminanceAt(x, y) >= threshold {
+				continue
+			}
+
+			// Union ignores empty rectangles, so the first pixel starts the box
+			box = box.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	return box
+}
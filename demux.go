@@ -0,0 +1,63 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// A chip select demultiplexer (e.g. a 74HC138) shared by several panels on one SPI bus,
+// for video walls with more panels than chip select lines. The CS pin of every panel is
+// wired to the enable input of the demux, active low, and the address pins to its
+// select inputs. Before each transfer the driver writes the index of the panel to the
+// address pins, most significant bit first, then pulls CS low so the demux routes it
+// to that panel only.
+//
+// Each panel is driven by its own Epd, created with WithDemux and the same Demux, so it
+// keeps its own frame buffer and mode. Panels still need their own DC, RST and BUSY pins.
+// The Demux serializes the transfers of all of them, so they can be updated in turn or
+// from different goroutines.
+type Demux struct {
+	mu   sync.Mutex
+	pins []int
+}
+
+// Creates a demux driven by the given address pins, up to 1<<len(pins) panels.
+func NewDemux(addressPins ...int) (*Demux, error) {
+	if len(addressPins) == 0 {
+		return nil, errors.New("demux needs at least one address pin")
+	}
+
+	return &Demux{pins: append([]int(nil), addressPins...)}, nil
+}
+
+// Selects the panel at index of the demux d, see Demux. Requires ChipSelectManual and a
+// backend shared by every panel of d, the default go-rpio one works.
+func WithDemux(d *Demux, index int) Option {
+	return func(e *Epd) error {
+		if d == nil {
+			return errors.New("demux must not be nil")
+		}
+		if index < 0 || index >= 1<<len(d.pins) {
+			return fmt.Errorf("demux index %d must be between 0 and %d", index, 1<<len(d.pins)-1)
+		}
+
+		e.demux = d
+		e.demuxIndex = index
+		return nil
+	}
+}
+
+// Locks the demux and routes the chip select to the panel at index.
+func (d *Demux) selectPanel(b Backend, index int) {
+	d.mu.Lock()
+
+	for i, pin := range d.pins {
+		bit := len(d.pins) - 1 - i
+		b.Write(pin, index&(1<<bit) != 0)
+	}
+}
+
+func (d *Demux) release() {
+	d.mu.Unlock()
+}
@@ -0,0 +1,96 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+var modeNames = map[initMode]string{
+	modeNone:    "none",
+	modeFull:    "full",
+	modeFast:    "fast",
+	modePartial: "partial",
+	modeGray4:   "gray4",
+}
+
+// Returns a human readable report of the driver configuration and state, meant to be
+// pasted in bug reports to tell wiring issues from software ones. It has no side effects.
+func (e *Epd) Diagnostics() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	csMode := "manual"
+	if e.csMode == ChipSelectHardware {
+		csMode = "hardware"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pins:         DC=%d CS=%d RST=%d BUSY=%d\n", e.dc, e.cs, e.rst, e.busy)
+	fmt.Fprintf(&b, "spi:          bus=%d chip=%d speed=%dHz chunk=%d cs=%s\n", e.spiBus, e.spiChip, e.spiSpeed, e.chunkSize, csMode)
+	fmt.Fprintf(&b, "bounds:       %v rotation=%d inverted=%t\n", e.logicalBounds(), e.rotation, e.inverted)
+	fmt.Fprintf(&b, "buffer size:  %d\n", e.bufferSize)
+	fmt.Fprintf(&b, "mode:         %s initialized=%t asleep=%t powered off=%t\n", modeNames[e.mode], e.initialized(), e.asleep, e.poweredOff)
+	fmt.Fprintf(&b, "busy:         %t\n", e.isBusy())
+	fmt.Fprintf(&b, "refreshes:    %d (%d partial since last full)\n", e.refreshCount, e.partialCount)
+	fmt.Fprintf(&b, "last refresh: %v\n", e.lastRefresh)
+	for i, cmd := range e.initSequence {
+		label := ""
+		if i == 0 {
+			label = "init:"
+		}
+		fmt.Fprintf(&b, "%-13s % x\n", label, cmd)
+	}
+	fmt.Fprintf(&b, "busy waits:   %d longest=%v last=%v\n", e.busyStats.Count, e.busyStats.Longest, e.busyStats.Last)
+
+	return b.String()
+}
+
+// Records the commands and data sent by the last init, see LastInitSequence. Meant to
+// debug clones and firmware variants of the panel, it is off by default.
+func WithInitRecording(enabled bool) Option {
+	return func(e *Epd) error {
+		e.recordInit = enabled
+		return nil
+	}
+}
+
+// Returns the commands sent by the last Init, InitFast, InitPartial or InitGray4 in
+// the order they were sent, each followed by its data, e.g. {0x01, 0x07, 0x07, 0x3F, 0x3F}
+// for POWER_SETTING. Only available with WithInitRecording, it is also part of Diagnostics.
+func (e *Epd) LastInitSequence() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sequence := make([][]byte, len(e.initSequence))
+	for i, cmd := range e.initSequence {
+		sequence[i] = append([]byte(nil), cmd...)
+	}
+	return sequence
+}
+
+// Pixel counts of an encoded frame, see BufferStats.
+type FrameStats struct {
+	Black int
+	White int
+	// Share of black pixels, from 0 to 1
+	BlackRatio float64
+	// Whether every pixel has the same color, often the sign of a wrong threshold
+	Uniform bool
+}
+
+// Counts the black and white pixels of a buffer returned by EncodeImage, e.g. to tell
+// why an image shows up all black or all white before sending it to the display.
+func BufferStats(buf []byte) FrameStats {
+	var stats FrameStats
+	for _, b := range buf {
+		stats.Black += bits.OnesCount8(b)
+	}
+
+	stats.White = len(buf)*PIXEL_SIZE - stats.Black
+	if len(buf) > 0 {
+		stats.BlackRatio = float64(stats.Black) / float64(len(buf)*PIXEL_SIZE)
+	}
+	stats.Uniform = stats.Black == 0 || stats.White == 0
+	return stats
+}
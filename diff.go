@@ -0,0 +1,212 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"context"
+	"image"
+)
+
+// Fraction of the screen that may change before DisplayImageDiff falls back to a full refresh.
+const DIFF_FULL_REFRESH_RATIO = 0.5
+
+// Same as DisplayImage but skips the refresh entirely when img encodes to exactly the
+// last frame sent to the display. Returns whether the display was refreshed.
+func (e *Epd) DisplayImageIfChanged(img image.Image) (bool, error) {
+	if err := checkImage(img); err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buffer := e.getBuffer(img, e.threshold)
+	defer e.releaseBuffer(buffer)
+	if bytes.Equal(buffer, e.lastBuffer) {
+		e.debug("Image unchanged")
+		return false, nil
+	}
+
+	if err := e.withRecovery(func() error {
+		return e.display(buffer)
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Compares img with the last frame sent to the display and only sends the bytes that
+// changed through a partial refresh of their bounding box. Nothing is sent when the
+// frame is unchanged, and a full refresh is done when more than DIFF_FULL_REFRESH_RATIO
+// of the screen changed, no previous frame is known, the planes of the panel were lost
+// by a reset or WithFullRefreshInterval is reached, clearing the ghosting. The display
+// is left in partial refresh mode.
+//
+// Since the first call after Init has nothing to compare with it always does a full
+// refresh. Requires InitPartial to be called first.
+func (e *Epd) DisplayImageDiff(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.debug("Displaying image diff")
+	buffer := e.getBuffer(img, e.threshold)
+	defer e.releaseBuffer(buffer)
+	return e.withRecovery(func() error {
+		return e.displayDiff(context.Background(), buffer)
+	})
+}
+
+// Sends only the bounding box of what changed in the full frame buffer, see DisplayImageDiff.
+func (e *Epd) displayDiff(ctx context.Context, buffer []byte) error {
+	if e.lastBuffer == nil {
+		return e.displayFull(ctx, buffer)
+	}
+
+	r := e.changedRegion(e.lastBuffer, buffer)
+	if r.Empty() {
+		e.debug("Image unchanged")
+		return nil
+	}
+
+	if float64(r.Dx()*r.Dy()) > DIFF_FULL_REFRESH_RATIO*float64(e.bounds.Dx()*e.bounds.Dy()) {
+		return e.displayFull(ctx, buffer)
+	}
+
+	// Promoted to a full refresh when the planes are unknown or the interval is reached
+	if err := e.sendPartial(ctx, e.cropBuffer(buffer, r), r); err != nil {
+		return err
+	}
+
+	e.debug("Image diff displayed")
+	return nil
+}
+
+// Displays a full frame buffer with a full refresh, going back to partial refresh mode
+// afterwards if the display was in it.
+func (e *Epd) displayFull(ctx context.Context, buffer []byte) error {
+	if e.mode != modePartial {
+		return e.displayContext(ctx, buffer)
+	}
+
+	return e.promotePartial(buffer, e.bounds)
+}
+
+// Returns the byte aligned bounding box, in native coordinates, of the bytes that
+// differ between two full frame buffers.
+func (e *Epd) changedRegion(a, b []byte) image.Rectangle {
+	var r image.Rectangle
+
+	for y := 0; y < e.bounds.Dy(); y++ {
+		for col := 0; col < e.pixelWidth; col++ {
+			i := y*e.pixelWidth + col
+			if a[i] == b[i] {
+				continue
+			}
+
+			r = r.Union(image.Rect(col*PIXEL_SIZE, y, (col+1)*PIXEL_SIZE, y+1))
+		}
+	}
+
+	return r.Intersect(e.bounds)
+}
+
+// Extracts the bytes of the byte aligned region r, in native coordinates, from a full
+// frame buffer.
+func (e *Epd) cropBuffer(buffer []byte, r image.Rectangle) []byte {
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	region := make([]byte, 0, rowSize*r.Dy())
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		start := y*e.pixelWidth + r.Min.X/PIXEL_SIZE
+		region = append(region, buffer[start:start+rowSize]...)
+	}
+
+	return region
+}
+
+// Changed bytes of a row closer than this many bytes are reported in the same region
+// by DiffBuffers, since sending a few unchanged bytes is cheaper than another refresh.
+const DIFF_MERGE_GAP = 2
+
+// Returns the byte aligned regions that differ between two full frame buffers, e.g. as
+// produced by EncodeImage, in the native coordinates of the panel. No region is
+// returned for identical frames, and the whole screen if the buffers don't have the
+// size of a frame.
+//
+// The changed bytes are grouped into a few bounding rectangles rather than reported
+// one by one: on each row, changed bytes at most DIFF_MERGE_GAP bytes apart form a
+// run, runs overlapping a region that reaches the row above extend it downwards and
+// finally regions that overlap or touch are merged. Each region can then be sent with
+// a partial refresh.
+func DiffBuffers(a, b []byte) []image.Rectangle {
+	screen := image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT)
+	if len(a) != ROW_SIZE*EPD_HEIGHT || len(b) != len(a) {
+		return []image.Rectangle{screen}
+	}
+
+	var regions []image.Rectangle
+	for y := 0; y < EPD_HEIGHT; y++ {
+		row := y * ROW_SIZE
+		for col := 0; col < ROW_SIZE; col++ {
+			if a[row+col] == b[row+col] {
+				continue
+			}
+
+			// Extend the run over the following changed bytes and small gaps
+			end := col + 1
+			for next := end; next < ROW_SIZE && next <= end+DIFF_MERGE_GAP; next++ {
+				if a[row+next] != b[row+next] {
+					end = next + 1
+				}
+			}
+
+			run := image.Rect(col*PIXEL_SIZE, y, end*PIXEL_SIZE, y+1)
+			regions = addRun(regions, run)
+			col = end - 1
+		}
+	}
+
+	return mergeRegions(regions, screen)
+}
+
+// Grows the region ending on the row above run and overlapping it horizontally, or
+// starts a new region.
+func addRun(regions []image.Rectangle, run image.Rectangle) []image.Rectangle {
+	for i, r := range regions {
+		if r.Max.Y >= run.Min.Y && r.Min.X < run.Max.X && run.Min.X < r.Max.X {
+			regions[i] = r.Union(run)
+			return regions
+		}
+	}
+
+	return append(regions, run)
+}
+
+// Merges the regions that overlap or touch until none do, and clamps them to screen.
+func mergeRegions(regions []image.Rectangle, screen image.Rectangle) []image.Rectangle {
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(regions) && !merged; i++ {
+			for j := i + 1; j < len(regions); j++ {
+				// Inset by -1 so regions sharing an edge count as touching
+				if !regions[i].Inset(-1).Overlaps(regions[j]) {
+					continue
+				}
+
+				regions[i] = regions[i].Union(regions[j])
+				regions = append(regions[:j], regions[j+1:]...)
+				merged = true
+				break
+			}
+		}
+	}
+
+	for i := range regions {
+		regions[i] = regions[i].Intersect(screen)
+	}
+
+	return regions
+}
@@ -0,0 +1,106 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"testing"
+)
+
+// Returns a white frame with the rectangles drawn in black.
+func diffFrame(black ...image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, r := range black {
+		draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+	}
+
+	return img
+}
+
+// Initializes a display in partial mode that shows a white frame.
+func newDiffEpd(t *testing.T, opts ...Option) (*Epd, *FakeBackend) {
+	t.Helper()
+
+	e, fake := newTestEpd(t, opts...)
+	if err := e.InitPartial(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.DisplayImageDiff(diffFrame()); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ClearTransfers()
+	return e, fake
+}
+
+func TestDisplayImageDiffSendsChangedWindow(t *testing.T) {
+	e, fake := newDiffEpd(t)
+
+	changed := image.Rect(40, 40, 48, 48)
+	if err := e.DisplayImageDiff(diffFrame(changed)); err != nil {
+		t.Fatal(err)
+	}
+
+	window := lastTransfer(t, fake, PARTIAL_WINDOW)
+	got := image.Rect(
+		int(window[0])<<8|int(window[1]),
+		int(window[4])<<8|int(window[5]),
+		(int(window[2])<<8|int(window[3]))+1,
+		(int(window[6])<<8|int(window[7]))+1,
+	)
+	if got != changed {
+		t.Errorf("sent window %v, want %v", got, changed)
+	}
+	if data := lastTransfer(t, fake, DISPLAY_START_TRANSMISSION_2); len(data) != changed.Dy() {
+		t.Errorf("sent %d bytes, want %d", len(data), changed.Dy())
+	}
+	if c := fake.Screen().GrayAt(44, 44); c.Y != 0 {
+		t.Errorf("changed pixel shows %v, want black", c)
+	}
+
+	fake.ClearTransfers()
+	if err := e.DisplayImageDiff(diffFrame(changed)); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.Transfers()) != 0 {
+		t.Error("unchanged frame was sent")
+	}
+}
+
+func TestDisplayImageDiffFullRefreshInterval(t *testing.T) {
+	e, fake := newDiffEpd(t, WithFullRefreshInterval(2))
+
+	for i, wantPartial := range []bool{true, false, true} {
+		fake.ClearTransfers()
+		if err := e.DisplayImageDiff(diffFrame(image.Rect(0, 0, 8, i+1))); err != nil {
+			t.Fatal(err)
+		}
+
+		if partial := bytes.IndexByte(fake.Commands(), PARTIAL_IN) >= 0; partial != wantPartial {
+			t.Errorf("update %d used a partial refresh: %v, want %v", i, partial, wantPartial)
+		}
+	}
+}
+
+func TestDisplayImageDiffAfterResetPromotes(t *testing.T) {
+	e, fake := newDiffEpd(t)
+	if err := e.InitPartial(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ClearTransfers()
+	if err := e.DisplayImageDiff(diffFrame(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) >= 0 {
+		t.Error("first diff after a reset used a partial window")
+	}
+	if c := fake.Screen().GrayAt(4, 4); c.Y != 0 {
+		t.Errorf("changed pixel shows %v, want black", c)
+	}
+	if c := fake.Screen().GrayAt(100, 100); c.Y != 255 {
+		t.Errorf("unchanged pixel shows %v, want white", c)
+	}
+}
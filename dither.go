@@ -0,0 +1,161 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Converts img into a pure black and white image using Floyd–Steinberg error
+// diffusion. The result can be displayed with DisplayImage or drawn onto a Canvas.
+func Dither(img image.Image) *image.Gray {
+	return dither(img, img.Bounds(), color.White)
+}
+
+// Same as DisplayImage but dithers the image first, which gives much better
+// results for photographic content than a flat threshold.
+func (e *Epd) DisplayImageDithered(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayImageDithered(img)
+	})
+}
+
+func (e *Epd) displayImageDithered(img image.Image) error {
+	e.debug("Displaying dithered image")
+	buffer := e.getBuffer(dither(atOrigin(img), e.logicalBounds(), e.background), 128)
+	defer e.releaseBuffer(buffer)
+	if err := e.display(buffer); err != nil {
+		return err
+	}
+	e.debug("Dithered image displayed")
+	return nil
+}
+
+// Runs Floyd–Steinberg over the pixels of img inside r, transparent pixels are
+// composited over bg.
+func dither(img image.Image, r image.Rectangle, bg color.Color) *image.Gray {
+	w, h := r.Dx(), r.Dy()
+
+	// Luminance of every pixel, with room to accumulate the diffused error
+	lum := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			lum[y*w+x] = int(Luminance(flatten(img.At(r.Min.X+x, r.Min.Y+y), bg)))
+		}
+	}
+
+	out := image.NewGray(r)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			old := lum[y*w+x]
+
+			value := 0
+			if old >= 128 {
+				value = 255
+			}
+			out.Pix[y*out.Stride+x] = uint8(value)
+
+			// Spread the quantization error to the neighbours not yet visited
+			diff := old - value
+			if x+1 < w {
+				lum[y*w+x+1] += diff * 7 / 16
+			}
+			if y+1 < h {
+				if x > 0 {
+					lum[(y+1)*w+x-1] += diff * 3 / 16
+				}
+				lum[(y+1)*w+x] += diff * 5 / 16
+				if x+1 < w {
+					lum[(y+1)*w+x+1] += diff * 1 / 16
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// A threshold map for ordered dithering. Each cell holds the rank of that position,
+// from 0 to n*n-1 for an n by n matrix.
+type DitherMatrix [][]uint8
+
+var (
+	Bayer4x4 = DitherMatrix{
+		{0, 8, 2, 10},
+		{12, 4, 14, 6},
+		{3, 11, 1, 9},
+		{15, 7, 13, 5},
+	}
+
+	Bayer8x8 = DitherMatrix{
+		{0, 32, 8, 40, 2, 34, 10, 42},
+		{48, 16, 56, 24, 50, 18, 58, 26},
+		{12, 44, 4, 36, 14, 46, 6, 38},
+		{60, 28, 52, 20, 62, 30, 54, 22},
+		{3, 35, 11, 43, 1, 33, 9, 41},
+		{51, 19, 59, 27, 49, 17, 57, 25},
+		{15, 47, 7, 39, 13, 45, 5, 37},
+		{63, 31, 55, 23, 61, 29, 53, 21},
+	}
+)
+
+// Checks that m has rows of equal, non zero length.
+func (m DitherMatrix) validate() error {
+	if len(m) == 0 || len(m[0]) == 0 {
+		return errors.New("dither matrix must not be empty")
+	}
+	for i, row := range m {
+		if len(row) != len(m[0]) {
+			return fmt.Errorf("dither matrix row %d has %d cells, want %d", i, len(row), len(m[0]))
+		}
+	}
+
+	return nil
+}
+
+// Returns the threshold for the pixel at x, y.
+func (m DitherMatrix) thresholdAt(x, y int) uint8 {
+	n := len(m)
+	cells := n * len(m[0])
+	rank := int(m[y%n][x%len(m[0])])
+
+	return uint8((2*rank + 1) * 256 / (2 * cells))
+}
+
+// Same as DisplayImage but with ordered dithering using matrix, e.g. Bayer4x4 or Bayer8x8.
+// Gives a stable halftone look that suits text heavy UIs better than error diffusion.
+func (e *Epd) DisplayImageOrderedDither(img image.Image, matrix DitherMatrix) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	if err := matrix.validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayImageOrderedDither(img, matrix)
+	})
+}
+
+func (e *Epd) displayImageOrderedDither(img image.Image, matrix DitherMatrix) error {
+	e.debug("Displaying ordered dithered image")
+	buffer := e.getBufferFunc(img, matrix.thresholdAt)
+	defer e.releaseBuffer(buffer)
+	if err := e.display(buffer); err != nil {
+		return err
+	}
+	e.debug("Ordered dithered image displayed")
+	return nil
+}
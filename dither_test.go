@@ -0,0 +1,25 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDisplayImageOrderedDitherRejectsInvalidMatrices(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	fake.ClearTransfers()
+
+	img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	for _, matrix := range []DitherMatrix{nil, {{}}, {{0, 2}, {3}}} {
+		if err := e.DisplayImageOrderedDither(img, matrix); err == nil {
+			t.Errorf("matrix %v was accepted", matrix)
+		}
+	}
+
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("invalid matrices sent % x", got)
+	}
+}
@@ -11,248 +11,941 @@
 package waveshare7in5v2
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"image"
+	"image/color"
 	"log"
+	"math"
+	"math/bits"
+	"sync"
+	"time"
 
 	"github.com/stianeikeland/go-rpio/v4"
 )
 
-// The driver to interact with the e-paper display
+// The register setup the display was last initialized with.
+type initMode int
+
+const (
+	modeNone initMode = iota
+	modeFull
+	modeFast
+	modePartial
+	modeGray4
+)
+
+// The driver to interact with the e-paper display. It is safe for concurrent use,
+// operations from multiple goroutines are serialized so their SPI sequences never
+// interleave.
 type Epd struct {
-	dc   rpio.Pin
-	cs   rpio.Pin
-	rst  rpio.Pin
-	busy rpio.Pin
+	backend Backend
+
+	dc   int
+	cs   int
+	rst  int
+	busy int
 
 	bounds     image.Rectangle
 	bufferSize int
 	pixelWidth int
+
+	spiBus     int
+	spiChip    int
+	miso       bool
+	csMode     ChipSelectMode
+	demux      *Demux
+	demuxIndex int
+	spiSpeed   int
+	chunkSize  int
+	spiRetries int
+	onProgress func(sent, total int)
+
+	busyTimeout    time.Duration
+	busyActiveHigh bool
+	pollInterval   time.Duration
+	edgeWait       bool
+	wiringCheck    bool
+	rereadDelay    time.Duration
+	rereadMax      int
+	resetPulse     time.Duration
+	resetSettle    time.Duration
+	sleepDelay     time.Duration
+	settleDelay    time.Duration
+	sleepFunc      func(time.Duration)
+	initRetries    int
+
+	rotation   int
+	mirrorX    bool
+	mirrorY    bool
+	inverted   bool
+	polarity   BufferPolarity
+	border     BorderColor
+	vcom       *vcomSetting
+	cdi        int
+	booster    [4]byte
+	threshold  uint8
+	gray4Gamma float64
+	grayLevels [3]uint8
+	letterbox  color.Color
+	background color.Color
+	filter     ImageFilter
+
+	logger    Logger
+	verbosity LogLevel
+
+	mu           sync.Mutex
+	mode         initMode
+	asleep       bool
+	poweredOff   bool
+	lastBuffer   []byte
+	lastHash     uint64
+	refreshCount int
+	lastRefresh  time.Duration
+	partialCount int
+	fullInterval int
+	autoSleep    time.Duration
+	robust       bool
+	warmup       int
+	warmedUp     bool
+	busyRecovery bool
+	wakeFlush    bool
+	needsFlush   bool
+	singlePlane  bool
+	planesSynced bool
+	frameValid   bool
+	sleepTimer   *time.Timer
+
+	// Changes made with SetPixel, in native coordinates
+	pendingPixels map[image.Point]bool
+	// End of the last refresh, see WaitSettled
+	lastRefreshEnd time.Time
+	// See BusyWaitStats
+	busyStats BusyWaitStats
+	// See WithInitRecording
+	recordInit   bool
+	recording    bool
+	initSequence [][]byte
+	// Frame buffer reused by getBuffer, see releaseBuffer
+	spareBuffer []byte
+
+	// See StartWatchdog
+	watchdog bool
+	onWedged func(cause, recoverErr error)
+
+	// Frame left on the panel when closing, see SetSplash
+	splash        []byte
+	splashOnClose bool
+
+	// Operations queued by DisplayImageAsync, guarded by queueMu rather than mu
+	queueMu      sync.Mutex
+	queue        []func()
+	queueRunning bool
 }
 
-func New() (*Epd, error) {
-	if err := rpio.Open(); err != nil {
-		return nil, err
+// Creates the driver and opens the SPI connection. Without any options the
+// default waveshare HAT wiring is used, see DEFAULT_DC_PIN and friends.
+func New(opts ...Option) (*Epd, error) {
+	bounds := image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT)
+	pixelWidth := ROW_SIZE
+	bufferSize := ROW_SIZE * EPD_HEIGHT
+
+	d := &Epd{
+		dc:   DEFAULT_DC_PIN,
+		cs:   DEFAULT_CS_PIN,
+		rst:  DEFAULT_RST_PIN,
+		busy: DEFAULT_BUSY_PIN,
+
+		bounds:     bounds,
+		bufferSize: bufferSize,
+		pixelWidth: pixelWidth,
+
+		spiSpeed:  DEFAULT_SPI_SPEED,
+		chunkSize: MAX_CHUNK_SIZE,
+
+		busyTimeout:  BUSY_TIMEOUT,
+		pollInterval: BUSY_POLL_INTERVAL,
+		resetPulse:   RESET_PULSE,
+		resetSettle:  RESET_SETTLE,
+		sleepDelay:   SLEEP_DELAY,
+		sleepFunc:    time.Sleep,
+		initRetries:  DEFAULT_INIT_RETRIES,
+
+		booster:    [4]byte{0x17, 0x17, 0x28, 0x17},
+		threshold:  DEFAULT_THRESHOLD,
+		gray4Gamma: 1,
+		grayLevels: defaultGray4Levels,
+		letterbox:  color.White,
+		background: color.White,
+
+		logger: log.Default(),
 	}
 
-	if err := rpio.SpiBegin(rpio.Spi0); err != nil {
-		return nil, err
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
+		}
 	}
 
-	rpio.SpiChipSelect(0)
+	if err := validatePins(d); err != nil {
+		return nil, err
+	}
 
-	dc := rpio.Pin(25)
-	cs := rpio.Pin(8)
-	rst := rpio.Pin(17)
-	busy := rpio.Pin(24)
+	if d.backend == nil {
+		backend := &rpioBackend{bus: rpio.SpiDev(d.spiBus), chip: uint8(d.spiChip)}
+		if d.miso {
+			d.backend = rpioReceiver{backend}
+		} else {
+			d.backend = backend
+		}
+	}
 
-	dc.Output()
-	cs.Output()
-	rst.Output()
-	busy.Input()
+	if err := d.backend.Open(); err != nil {
+		return nil, err
+	}
 
-	bounds := image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT)
-	pixelWidth := EPD_WIDTH / PIXEL_SIZE
-	bufferSize := pixelWidth * EPD_HEIGHT
+	d.backend.SetSpeed(d.spiSpeed)
 
-	d := &Epd{
-		dc:   dc,
-		cs:   cs,
-		rst:  rst,
-		busy: busy,
+	d.backend.Output(d.dc)
+	if d.csMode == ChipSelectManual {
+		d.backend.Output(d.cs)
+	}
+	d.backend.Output(d.rst)
+	d.backend.Input(d.busy)
+	if d.demux != nil {
+		for _, pin := range d.demux.pins {
+			d.backend.Output(pin)
+		}
+	}
 
-		bounds:     bounds,
-		bufferSize: bufferSize,
-		pixelWidth: pixelWidth,
+	if d.wiringCheck {
+		if err := d.verifyWiring(); err != nil {
+			d.backend.Close()
+			d.backend.CloseGPIO()
+			return nil, err
+		}
 	}
 
 	return d, nil
 }
 
+// Returns the resolution setting (TRES) for a panel of width sources by height gates,
+// both as 16 bit big endian values: 0x03 0x20 0x01 0xE0 for 800x480.
+func resolutionSetting(width, height int) []byte {
+	return []byte{
+		byte(width >> 8),
+		byte(width),
+		byte(height >> 8),
+		byte(height),
+	}
+}
+
+// Returns the SPI clock speed in Hz.
+func (e *Epd) SPISpeed() int {
+	return e.spiSpeed
+}
+
 // Powers on the screen after power off or sleep.
-func (e *Epd) Init() {
-	log.Println("Initializing display")
-	e.reset()
+// Returns ErrResetFailed or ErrPowerOnTimeout if the display never releases the busy line,
+// after starting over a few times when it doesn't power on, see WithInitRetries.
+func (e *Epd) Init() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.robust = false
+	return e.initFullWarm()
+}
 
-	e.sendCommandWithData(0x01, // POWER_SETTING
+// Same as Init but only runs when the display isn't already initialized and awake,
+// i.e. on first use or after Sleep or Close. Safe to call before every update.
+func (e *Epd) InitIfNeeded() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.initialized() {
+		return nil
+	}
+
+	return e.initFullWarm()
+}
+
+// Reports whether the display is initialized and awake, i.e. ready to show images
+// without running Init first.
+func (e *Epd) Initialized() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.initialized()
+}
+
+func (e *Epd) initialized() bool {
+	return e.mode != modeNone && !e.asleep
+}
+
+func (e *Epd) initFull() error {
+	return e.retryInit(e.initFullOnce)
+}
+
+func (e *Epd) initFullOnce() error {
+	e.debug("Initializing display")
+	if err := e.reset(); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(0x01, // POWER_SETTING
 		[]byte{
 			0x07,
 			0x07, // VGH=20V,VGL=-20V
 			0x3f, // VDH=15v
 			0x3f, // VDL=-15v
-		})
+		}); err != nil {
+		return err
+	}
 
-	e.sendCommandWithData(0x06, // BOOSTER_SOFT_START
-		[]byte{
-			0x17,
-			0x17,
-			0x28,
-			0x17,
-		})
+	if err := e.sendCommandWithData(0x06, // BOOSTER_SOFT_START
+		e.booster[:]); err != nil {
+		return err
+	}
 
-	e.sendCommand(0x04) // POWER_ON
-	wait(100)
-	e.waitUntilIdle() // waiting for the electronic paper IC to release the idle signal
+	if e.robust {
+		if err := e.robustPowerOn(); err != nil {
+			return err
+		}
+	} else {
+		if err := e.sendCommand(0x04); err != nil { // POWER_ON
+			return err
+		}
+		e.wait(100 * time.Millisecond)
+		// waiting for the electronic paper IC to release the idle signal
+		if err := e.waitUntilIdle(); err != nil {
+			return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+		}
+	}
 
-	e.sendCommandWithData(0x00, // PANEL_SETTING
+	if err := e.sendCommandWithData(0x00, // PANEL_SETTING
 		[]byte{
 			0x1F, // KW-3f  KWR-2F	BWROTP 0f	BWOTP 1f
-		})
+		}); err != nil {
+		return err
+	}
 
-	e.sendCommandWithData(0x61, //tres
-		[]byte{
-			0x03, // source 800
-			0x20,
-			0x01, // gate 480
-			0xE0,
-		})
+	if err := e.sendCommandWithData(RESOLUTION_SETTING, resolutionSetting(e.bounds.Dx(), e.bounds.Dy())); err != nil {
+		return err
+	}
 
-	e.sendCommandWithData(0x15, []byte{0x00})
+	if err := e.sendCommandWithData(0x15, []byte{0x00}); err != nil {
+		return err
+	}
 
-	e.sendCommandWithData(0x50, // VCOM AND DATA INTERVAL SETTING
-		[]byte{
-			0x10,
-			0x17,
-		})
+	if err := e.sendVCOMDataInterval(modeFull); err != nil { // VCOM AND DATA INTERVAL SETTING
+		return err
+	}
 
-	e.sendCommandWithData(0x52, []byte{
+	if err := e.sendCommandWithData(0x52, []byte{
 		0x03,
-	})
+	}); err != nil {
+		return err
+	}
 
-	e.sendCommandWithData(0x60, []byte{ // TCON SETTING
+	if err := e.sendCommandWithData(0x60, []byte{ // TCON SETTING
 		0x22,
-	})
+	}); err != nil {
+		return err
+	}
 
-	log.Println("Display initialized")
+	e.asleep = false
+	e.poweredOff = false
+	e.mode = modeFull
+	e.debug("Display initialized")
+	return nil
 }
 
-// Returns the current screen bounds
+// Returns the current screen bounds, taking the rotation into account
 func (e *Epd) Bounds() image.Rectangle {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.logicalBounds()
+}
+
+func (e *Epd) logicalBounds() image.Rectangle {
+	if e.rotation == 90 || e.rotation == 270 {
+		return image.Rect(0, 0, e.bounds.Dy(), e.bounds.Dx())
+	}
+
 	return e.bounds
 }
 
 // Converts an image into a buffer array ready to be sent to the display.
+// The top left corner of img is drawn at the top left corner of the screen.
 // Due to the display only supporting 2 colors a threshold is applied to convert the image to pure black and white.
-// The returned buffer is ready to be sent using UpdateFrame.
+// The returned buffer is ready to be sent using UpdateFrame. Once it is no longer
+// needed it can be handed back with releaseBuffer, so consecutive frames don't
+// allocate a new buffer each.
 func (e *Epd) getBuffer(img image.Image, threshold uint8) []byte {
-	log.Println("Getting buffer")
-	buffer := make([]byte, e.bufferSize)
+	return e.getBufferFunc(img, func(x, y int) uint8 {
+		return threshold
+	})
+}
+
+// Same as getBuffer but the threshold can vary per pixel, see getRegionBufferFunc.
+func (e *Epd) getBufferFunc(img image.Image, thresholdAt func(x, y int) uint8) []byte {
+	e.debug("Getting buffer")
+	buffer := e.spareBuffer
+	if buffer == nil {
+		buffer = make([]byte, e.bufferSize)
+	}
+	e.spareBuffer = nil
+
+	e.encodeRegion(buffer, e.applyFilter(atOrigin(img)), e.bounds, thresholdAt)
+	e.debug("Buffer ready")
+	return buffer
+}
+
+// Hands a buffer returned by getBuffer back for reuse, it must not be used afterwards.
+// The frame on screen is kept in lastBuffer, which is a copy.
+func (e *Epd) releaseBuffer(buffer []byte) {
+	e.spareBuffer = buffer
+}
 
-	for y := 0; y < e.bounds.Dy(); y++ {
-		for x := 0; x < e.bounds.Dx(); x += PIXEL_SIZE {
+// Converts an image into a buffer ready to be sent to the display, pixels darker than
+// threshold become black. Unlike the Epd methods it doesn't log nor need a device, so
+// frames can be encoded ahead of time or on another machine. The image is encoded in
+// the native orientation of the panel, without rotation or inversion.
+func EncodeImage(img image.Image, threshold uint8) []byte {
+	e := &Epd{bounds: image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT), background: color.White}
+	return e.getRegionBuffer(atOrigin(img), e.bounds, threshold)
+}
+
+// Same as EncodeImage but writes the buffer into dst instead of allocating a new one,
+// so animations can reuse or pool their buffers. dst must be exactly ROW_SIZE*EPD_HEIGHT
+// (48000) bytes long, see Config().BufferSize, otherwise ErrBufferSize is returned.
+// Every byte of dst is overwritten.
+func EncodeImageInto(dst []byte, img image.Image, threshold uint8) error {
+	e := &Epd{
+		bounds:     image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT),
+		bufferSize: ROW_SIZE * EPD_HEIGHT,
+		background: color.White,
+	}
+	if err := e.checkBufferSize(dst); err != nil {
+		return err
+	}
+
+	e.encodeRegion(dst, atOrigin(img), e.bounds, func(x, y int) uint8 {
+		return threshold
+	})
+	return nil
+}
+
+// Same as EncodeImage but applies a gamma curve to the luminance before the threshold,
+// out = in^(1/gamma). A gamma above 1 brightens the shadows and below 1 darkens the
+// highlights, 1 keeps the image as is. Non positive values are treated as 1.
+func EncodeImageGamma(img image.Image, threshold uint8, gamma float64) []byte {
+	if gamma <= 0 {
+		gamma = 1
+	}
+
+	// The curve is monotonic, so comparing the corrected luminance against threshold
+	// is the same as comparing the original one against the inverse of threshold
+	adjusted := math.Round(255 * math.Pow(float64(threshold)/255, gamma))
+	return EncodeImage(img, uint8(adjusted))
+}
+
+// Same as EncodeImage but pixels within tolerance of key are black whatever their
+// luminance, e.g. to turn the red accents of a UI designed in color black. A pixel is
+// within tolerance when none of its red, green and blue components, from 0 to 255,
+// differ from those of key by more than tolerance. Other pixels use threshold.
+func EncodeImageColorKey(img image.Image, key color.Color, tolerance, threshold uint8) []byte {
+	return EncodeImage(colorKeyed{img, color.RGBAModel.Convert(key).(color.RGBA), tolerance}, threshold)
+}
+
+// An image reading as black where its pixels are close to a key color.
+type colorKeyed struct {
+	image.Image
+	key       color.RGBA
+	tolerance uint8
+}
+
+func (c colorKeyed) At(x, y int) color.Color {
+	p := color.RGBAModel.Convert(c.Image.At(x, y)).(color.RGBA)
+	if absDiff(p.R, c.key.R) <= c.tolerance && absDiff(p.G, c.key.G) <= c.tolerance && absDiff(p.B, c.key.B) <= c.tolerance {
+		return color.Black
+	}
+
+	return p
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// The order pixels are packed in each byte of a buffer.
+type BitOrder int
+
+const (
+	// The leftmost pixel is the most significant bit, as the display expects.
+	MSBFirst BitOrder = iota
+	// The leftmost pixel is the least significant bit.
+	LSBFirst
+)
+
+// Same as EncodeImage but packs the pixels in the given bit order, to exchange buffers
+// with tools that expect LSB first. Only MSBFirst buffers can be sent to the display.
+func EncodeImageOrder(img image.Image, threshold uint8, order BitOrder) []byte {
+	buffer := EncodeImage(img, threshold)
+	if order == LSBFirst {
+		return ReverseBitOrder(buffer)
+	}
+
+	return buffer
+}
+
+// Returns a copy of buffer with the bits of every byte reversed, converting between
+// MSBFirst and LSBFirst.
+func ReverseBitOrder(buffer []byte) []byte {
+	reversed := make([]byte, len(buffer))
+	for i, b := range buffer {
+		reversed[i] = bits.Reverse8(b)
+	}
+
+	return reversed
+}
+
+// Same as getBuffer but only converts the pixels inside r, which is in native panel
+// coordinates and must be byte aligned on the x axis. The returned buffer has
+// r.Dx()/PIXEL_SIZE bytes per row, rounded up.
+// Pixels outside of the image or the panel are treated as white.
+func (e *Epd) getRegionBuffer(img image.Image, r image.Rectangle, threshold uint8) []byte {
+	return e.getRegionBufferFunc(img, r, func(x, y int) uint8 {
+		return threshold
+	})
+}
+
+// Same as getRegionBuffer but the threshold can vary per pixel, thresholdAt is called
+// with the coordinates of each pixel of img.
+func (e *Epd) getRegionBufferFunc(img image.Image, r image.Rectangle, thresholdAt func(x, y int) uint8) []byte {
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	buffer := make([]byte, rowSize*r.Dy())
+	e.encodeRegion(buffer, img, r, thresholdAt)
+	return buffer
+}
+
+// Writes the pixels of img inside r into buffer, which must hold r.Dx()/PIXEL_SIZE
+// bytes per row, rounded up.
+func (e *Epd) encodeRegion(buffer []byte, img image.Image, r image.Rectangle, thresholdAt func(x, y int) uint8) {
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	imgBounds := img.Bounds()
+	luminanceAt := e.luminanceFunc(img)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x += PIXEL_SIZE {
 			// Start with white
 			var pixel byte = 0x00
 
 			// Iterate and append over the next 8 pixels
 			for px := 0; px < PIXEL_SIZE; px++ {
-				if isBlack(img.At(x+px, y), threshold) {
+				black := false
+				if x+px < e.bounds.Max.X {
+					lx, ly := e.toLogical(x+px, y)
+					black = image.Pt(lx, ly).In(imgBounds) && luminanceAt(lx, ly) < thresholdAt(lx, ly)
+				}
+
+				if black != e.inverted {
 					pixel |= (0x80 >> px)
 				}
 			}
 
-			buffer[(y*e.pixelWidth + x/PIXEL_SIZE)] = pixel
+			buffer[((y-r.Min.Y)*rowSize + (x-r.Min.X)/PIXEL_SIZE)] = pixel
 		}
 	}
+}
 
-	log.Println("Buffer ready")
-	return buffer
+// Returns a function reading the luminance of the pixels of img. Gray, paletted and
+// opaque RGBA pixels are read directly from the image, avoiding a color.Color per pixel.
+func (e *Epd) luminanceFunc(img image.Image) func(x, y int) uint8 {
+	switch img := img.(type) {
+	case *image.Gray:
+		return func(x, y int) uint8 {
+			return img.Pix[img.PixOffset(x, y)]
+		}
+	case *image.RGBA:
+		return func(x, y int) uint8 {
+			p := img.Pix[img.PixOffset(x, y):]
+			if p[3] != 0xff {
+				return Luminance(flatten(img.RGBAAt(x, y), e.background))
+			}
+
+			return luminance(uint32(p[0])*0x101, uint32(p[1])*0x101, uint32(p[2])*0x101)
+		}
+	case *image.YCbCr:
+		// What JPEG decodes to, Y already is the luminance with the same weights
+		return func(x, y int) uint8 {
+			return img.Y[img.YOffset(x, y)]
+		}
+	case *image.Paletted:
+		lum := make([]uint8, len(img.Palette))
+		for i, c := range img.Palette {
+			lum[i] = Luminance(flatten(c, e.background))
+		}
+
+		return func(x, y int) uint8 {
+			if i := int(img.Pix[img.PixOffset(x, y)]); i < len(lum) {
+				return lum[i]
+			}
+			// Out of range indexes, which Paletted.At panics on, read as black
+			return 0
+		}
+	default:
+		return func(x, y int) uint8 {
+			return Luminance(flatten(img.At(x, y), e.background))
+		}
+	}
 }
 
-func (e *Epd) display(buffer []byte) {
-	log.Println("Displaying buffer")
-	e.sendCommand(0x10)
-	e.sendData(buffer)
+func (e *Epd) display(buffer []byte) error {
+	return e.displayContext(context.Background(), buffer)
+}
 
-	e.sendCommand(0x13)
-	e.sendData(buffer)
+func (e *Epd) displayContext(ctx context.Context, buffer []byte) error {
+	if err := e.wake(); err != nil {
+		return err
+	}
 
-	e.turnOnDisplay()
-	log.Println("Buffer displayed")
+	e.debug("Displaying buffer")
+	// The old plane still holds the previous frame, see WithSinglePlaneWrites
+	if !e.singlePlane || !e.planesSynced {
+		if err := e.sendCommandWithData(0x10, buffer); err != nil {
+			return err
+		}
+	}
+
+	if err := e.sendCommandWithData(0x13, buffer); err != nil {
+		return err
+	}
+	e.planesWritten(buffer)
+
+	if e.wakeFlush && e.needsFlush {
+		// Refresh twice, the first one only cleans what was left before the reset
+		e.debug("Flushing display")
+		if err := e.turnOnDisplayContext(ctx); err != nil {
+			return err
+		}
+	}
+	e.needsFlush = false
+
+	if err := e.turnOnDisplayContext(ctx); err != nil {
+		return err
+	}
+	e.debug("Buffer displayed")
+	return nil
 }
 
-func (e *Epd) turnOnDisplay() {
-	log.Println("Turning on display")
-	e.sendCommand(0x12)
-	wait(100)
-	e.waitUntilIdle()
-	log.Println("Display turned on")
+func (e *Epd) turnOnDisplay() error {
+	return e.turnOnDisplayContext(context.Background())
+}
+
+func (e *Epd) turnOnDisplayContext(ctx context.Context) error {
+	e.debug("Turning on display")
+	start := time.Now()
+	if err := e.sendCommand(0x12); err != nil {
+		return err
+	}
+	e.wait(100 * time.Millisecond)
+	if err := e.waitUntilIdleContext(ctx); err != nil {
+		return err
+	}
+	e.lastRefreshEnd = time.Now()
+	e.lastRefresh = e.lastRefreshEnd.Sub(start)
+	e.refreshCount++
+	if e.settleDelay > 0 {
+		e.wait(e.settleDelay)
+	}
+	e.scheduleAutoSleep()
+	e.debug("Display turned on")
+	return nil
 }
 
 // Allows to easily send an image.Image directly to the screen.
-func (e *Epd) DisplayImage(img image.Image) {
-	log.Println("Displaying image")
-	buffer := e.getBuffer(img, 199)
-	e.display(buffer)
-	log.Println("Image displayed")
+func (e *Epd) DisplayImage(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayImageThreshold(img, e.threshold)
+	})
+}
+
+// Same as DisplayImage but with a custom threshold. Pixels with a luminance below
+// threshold are displayed as black and everything else as white.
+func (e *Epd) DisplayImageThreshold(img image.Image, threshold uint8) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayImageThreshold(img, threshold)
+	})
+}
+
+func (e *Epd) displayImageThreshold(img image.Image, threshold uint8) error {
+	e.debug("Displaying image")
+	buffer := e.getBuffer(img, threshold)
+	defer e.releaseBuffer(buffer)
+	if err := e.display(buffer); err != nil {
+		return err
+	}
+	e.debug("Image displayed")
+	return nil
+}
+
+// Same as DisplayImage but stops waiting for the refresh to complete once ctx is done,
+// returning ctx.Err(). Data already sent to the display is not interrupted.
+func (e *Epd) DisplayImageContext(ctx context.Context, img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayImageContext(ctx, img)
+	})
+}
+
+func (e *Epd) displayImageContext(ctx context.Context, img image.Image) error {
+	e.debug("Displaying image")
+	buffer := e.getBuffer(img, e.threshold)
+	defer e.releaseBuffer(buffer)
+	if err := e.displayContext(ctx, buffer); err != nil {
+		return err
+	}
+	e.debug("Image displayed")
+	return nil
 }
 
 // Clear the buffer and updates the screen right away.
-func (e *Epd) Clear() {
-	log.Println("Clearing display")
+func (e *Epd) Clear() error {
+	return e.ClearColor(false)
+}
 
-	w := 0
-	if EPD_WIDTH%PIXEL_SIZE == 0 {
-		w = EPD_WIDTH / PIXEL_SIZE
-	} else {
-		w = EPD_WIDTH/PIXEL_SIZE + 1
+// Same as Clear but fills the screen with solid black when black is true, which is
+// handy to reset ghosting and for dark themed UIs.
+func (e *Epd) ClearColor(black bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.clear(black)
+	})
+}
+
+func (e *Epd) clear(black bool) error {
+	if err := e.wake(); err != nil {
+		return err
 	}
-	h := EPD_HEIGHT
 
-	img := make([]byte, EPD_WIDTH/8)
-	e.sendCommand(0x10)
-	for i := 0; i < w; i++ {
-		img[i] = 0xFF
+	e.debug("Clearing display")
+
+	var fill byte = 0x00
+	if black != e.inverted {
+		fill = 0xFF
 	}
-	for i := 0; i < h; i++ {
-		e.sendData(img)
+
+	// Rows have the same stride as the buffers built by getBuffer
+	img := bytes.Repeat([]byte{^fill}, e.pixelWidth)
+	if err := e.sendCommand(0x10); err != nil {
+		return err
+	}
+	for i := 0; i < e.bounds.Dy(); i++ {
+		if err := e.sendData(img); err != nil {
+			return err
+		}
 	}
 
-	e.sendCommand(0x13)
-	for i := 0; i < w; i++ {
-		img[i] = 0x00
+	if err := e.sendCommand(0x13); err != nil {
+		return err
+	}
+	for i := range img {
+		img[i] = fill
 	}
-	for i := 0; i < h; i++ {
-		e.sendData(img)
+	for i := 0; i < e.bounds.Dy(); i++ {
+		if err := e.sendData(img); err != nil {
+			return err
+		}
 	}
 
-	e.turnOnDisplay()
+	e.storeBuffer(bytes.Repeat([]byte{fill}, e.bufferSize))
+	e.partialCount = 0
+	e.frameValid = true
+	// Every pixel is driven from the opposite color, no flush needed
+	e.needsFlush = false
 
-	log.Println("Display cleared")
+	if err := e.turnOnDisplay(); err != nil {
+		return err
+	}
+
+	e.debug("Display cleared")
+	return nil
 }
 
 // Puts the display to sleep and powers off. This helps ensure the display longevity
 // since keeping it powered on for long periods of time can damage the screen.
-// After Sleep the display needs to be woken up by running Init again
-func (e *Epd) Sleep() {
-	log.Println("Putting display to sleep")
-	e.sendCommand(POWER_OFF)
-	e.waitUntilIdle()
+// After Sleep the display needs to be woken up by running Wake or Init again
+func (e *Epd) Sleep() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.sleep()
+}
 
-	e.sendCommandWithData(DEEP_SLEEP, []byte{0xa5})
+func (e *Epd) sleep() error {
+	e.debug("Putting display to sleep")
+	if err := e.sendCommand(POWER_OFF); err != nil {
+		return err
+	}
+	if err := e.waitUntilIdle(); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(DEEP_SLEEP, []byte{0xa5}); err != nil {
+		return err
+	}
 
-	wait(2000)
+	e.wait(e.sleepDelay)
 
-	log.Println("Display is asleep")
+	e.asleep = true
+	e.poweredOff = false
+	e.debug("Display is asleep")
+	return nil
 }
 
-// Powers off the display and closes the SPI connection.
-func (e *Epd) Close() {
-	log.Println("Closing display")
-	e.cs.Write(rpio.Low)
-	e.dc.Write(rpio.Low)
-	e.rst.Write(rpio.Low)
+// Powers off the display and closes the SPI connection. GPIO is left open so other
+// devices driven by the same program keep working, use CloseGPIO for a full teardown.
+// The connection is closed even if displaying the splash fails, see WithSplashOnClose.
+func (e *Epd) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	rpio.SpiEnd(rpio.Spi0)
-	rpio.Close()
-	log.Println("Display closed")
+	splashErr := e.showSplash()
+	if err := e.close(); err != nil {
+		return err
+	}
+
+	return splashErr
 }
 
-func (e *Epd) reset() {
-	log.Println("Resetting display")
-	e.rst.Write(rpio.High)
-	wait(20)
-	e.rst.Write(rpio.Low)
-	wait(2)
-	e.rst.Write(rpio.High)
-	wait(20)
-	log.Println("Display reset")
+func (e *Epd) close() error {
+	e.debug("Closing display")
+	if e.sleepTimer != nil {
+		e.sleepTimer.Stop()
+		e.sleepTimer = nil
+	}
+
+	// With a demux the CS line is shared with the other panels
+	if e.demux == nil {
+		e.selectChip(true)
+	}
+	e.backend.Write(e.dc, false)
+	e.backend.Write(e.rst, false)
+	e.mode = modeNone
+
+	if err := e.backend.Close(); err != nil {
+		return err
+	}
+	e.debug("Display closed")
+	return nil
+}
+
+// Same as Close but also closes the GPIO memory mapping shared by the whole process.
+// When several devices share go-rpio, only call it once all of them are done.
+func (e *Epd) CloseGPIO() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	splashErr := e.showSplash()
+	if err := e.close(); err != nil {
+		return err
+	}
+
+	if err := e.backend.CloseGPIO(); err != nil {
+		return err
+	}
+	return splashErr
+}
+
+// Puts the display to sleep and closes the connection, the recommended way to end a
+// session since closing without sleeping leaves the panel powered. The connection is
+// closed even if Sleep fails, in which case its error is returned. The splash is
+// displayed before going to sleep, see WithSplashOnClose.
+func (e *Epd) Shutdown() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sleepErr := e.showSplash()
+	if !e.asleep {
+		if err := e.sleep(); err != nil && sleepErr == nil {
+			sleepErr = err
+		}
+	}
+
+	if err := e.close(); err != nil && sleepErr == nil {
+		return err
+	}
+
+	return sleepErr
+}
+
+// Resets the display through its RST pin, without running any init sequence. Useful to
+// recover a display stuck busy, Init must be called afterwards.
+// Returns ErrResetFailed if the display doesn't come out of reset.
+func (e *Epd) Reset() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.reset(); err != nil {
+		return err
+	}
+
+	// The registers are back to their defaults
+	e.mode = modeNone
+	e.asleep = false
+	e.poweredOff = false
+	return nil
+}
+
+func (e *Epd) reset() error {
+	e.debug("Resetting display")
+	e.needsFlush = true
+	e.planesSynced = false
+	// The planes are unknown until a full frame or restorePlanes writes them
+	e.frameValid = false
+
+	pulse, settle := e.resetPulse, e.resetSettle
+	if e.robust {
+		pulse, settle = pulse*ROBUST_DELAY_FACTOR, settle*ROBUST_DELAY_FACTOR
+	}
+
+	e.backend.Write(e.rst, true)
+	e.wait(settle)
+	e.backend.Write(e.rst, false)
+	e.wait(pulse)
+	e.backend.Write(e.rst, true)
+	e.wait(settle)
+	if err := e.waitUntilIdle(); err != nil {
+		return fmt.Errorf("%w: %v", ErrResetFailed, err)
+	}
+	e.debug("Display reset")
+	return nil
 }
@@ -0,0 +1,168 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"testing"
+)
+
+func TestDisplayImagePadsPartialBytes(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 13 pixels wide, the second byte of each row only holds 5 of them
+	img := image.NewGray(image.Rect(0, 0, 13, 2))
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+
+	fake.ClearTransfers()
+	if err := e.DisplayImage(img); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{DISPLAY_START_TRANSMISSION_1, DISPLAY_START_TRANSMISSION_2, DISPLAY_REFRESH}
+	if got := fake.Commands(); !bytes.Contains(got, want) {
+		t.Errorf("commands % x, want them to contain % x", got, want)
+	}
+
+	for _, cmd := range []byte{DISPLAY_START_TRANSMISSION_1, DISPLAY_START_TRANSMISSION_2} {
+		data := lastTransfer(t, fake, cmd)
+		if len(data) != ROW_SIZE*EPD_HEIGHT {
+			t.Fatalf("0x%02x sent %d bytes, want %d", cmd, len(data), ROW_SIZE*EPD_HEIGHT)
+		}
+
+		for y := 0; y < 3; y++ {
+			row := data[y*ROW_SIZE : (y+1)*ROW_SIZE]
+			want := make([]byte, ROW_SIZE)
+			if y < 2 {
+				want[0], want[1] = 0xFF, 0xF8
+			}
+			if !bytes.Equal(row, want) {
+				t.Errorf("0x%02x row %d starts with % x, want % x", cmd, y, row[:3], want[:3])
+			}
+		}
+	}
+}
+
+func TestDisplayImageNonZeroOrigin(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A black square at 10,10 of a larger canvas, cropped from 8,4
+	canvas := image.NewGray(image.Rect(0, 0, EPD_WIDTH+8, EPD_HEIGHT+4))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(10, 10, 26, 26), image.Black, image.Point{}, draw.Src)
+	sub := canvas.SubImage(image.Rect(8, 4, EPD_WIDTH+8, EPD_HEIGHT+4))
+
+	fake.ClearTransfers()
+	if err := e.DisplayImage(sub); err != nil {
+		t.Fatal(err)
+	}
+
+	// The square lands at 2,6 on screen
+	want := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(want, want.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(want, image.Rect(2, 6, 18, 22), image.Black, image.Point{}, draw.Src)
+
+	if got := lastTransfer(t, fake, DISPLAY_START_TRANSMISSION_2); !bytes.Equal(got, EncodeImage(want, DEFAULT_THRESHOLD)) {
+		t.Error("sub image wasn't translated to the origin of the screen")
+	}
+	if got := fake.Screen(); !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("screen doesn't show the sub image")
+	}
+}
+
+func TestClearMatchesBufferStride(t *testing.T) {
+	for _, black := range []bool{false, true} {
+		e, fake := newTestEpd(t)
+		if err := e.Init(); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.ClearTransfers()
+		if err := e.ClearColor(black); err != nil {
+			t.Fatal(err)
+		}
+
+		img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+		fill := image.White
+		if black {
+			fill = image.Black
+		}
+		draw.Draw(img, img.Bounds(), fill, image.Point{}, draw.Src)
+		want := EncodeImage(img, DEFAULT_THRESHOLD)
+
+		if got := lastTransfer(t, fake, DISPLAY_START_TRANSMISSION_2); !bytes.Equal(got, want) {
+			t.Errorf("black %v: Clear sent %d bytes, want the %d of an encoded frame", black, len(got), len(want))
+		}
+		if len(want) != e.Config().BufferSize || e.Config().BufferSize != ROW_SIZE*EPD_HEIGHT {
+			t.Errorf("buffer size %d, want %d", e.Config().BufferSize, ROW_SIZE*EPD_HEIGHT)
+		}
+	}
+}
+
+func TestDisplayImageRejectsNilAndEmptyImages(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	fake.ClearTransfers()
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want error
+	}{
+		{"nil", nil, ErrNilImage},
+		{"typed nil", (*image.Gray)(nil), ErrNilImage},
+		{"empty", image.NewGray(image.Rectangle{}), ErrEmptyImage},
+	}
+
+	for _, tt := range tests {
+		if err := e.DisplayImage(tt.img); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+		if err := e.DisplayPartial(tt.img, image.Rect(0, 0, 8, 8)); !errors.Is(err, tt.want) {
+			t.Errorf("%s partial: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("invalid images sent % x", got)
+	}
+}
+
+func TestInitSendsResolution(t *testing.T) {
+	want := []byte{0x03, 0x20, 0x01, 0xE0}
+	if got := resolutionSetting(800, 480); !bytes.Equal(got, want) {
+		t.Errorf("resolution setting % x, want % x", got, want)
+	}
+
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastTransfer(t, fake, RESOLUTION_SETTING); !bytes.Equal(got, want) {
+		t.Errorf("Init sent resolution % x, want % x", got, want)
+	}
+
+	// The simulated panel takes its size from the resolution setting
+	img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(img, image.Rect(EPD_WIDTH-8, EPD_HEIGHT-1, EPD_WIDTH, EPD_HEIGHT), image.White, image.Point{}, draw.Src)
+	if err := e.DisplayImage(img); err != nil {
+		t.Fatal(err)
+	}
+
+	screen := fake.Screen()
+	if screen.Bounds() != img.Bounds() {
+		t.Fatalf("screen bounds %v, want %v", screen.Bounds(), img.Bounds())
+	}
+	if !bytes.Equal(screen.Pix, img.Pix) {
+		t.Error("screen doesn't show the image")
+	}
+}
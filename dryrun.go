@@ -0,0 +1,26 @@
+package waveshare7in5v2
+
+import "time"
+
+// Creates a driver that doesn't talk to any hardware, every SPI and GPIO operation is
+// a no-op, the display is always reported idle and the driver never waits. The geometry
+// matches the real panel so the whole rendering pipeline can be run on a laptop, combine
+// it with SaveBuffer to check the result.
+func NewDryRun(opts ...Option) (*Epd, error) {
+	noWait := func(time.Duration) {}
+	return New(append([]Option{WithBackend(nopBackend{}), WithSleepFunc(noWait)}, opts...)...)
+}
+
+// A Backend discarding everything, see NewDryRun. Reads return high so the busy
+// line always reports the display as idle.
+type nopBackend struct{}
+
+func (nopBackend) Open() error                { return nil }
+func (nopBackend) Close() error               { return nil }
+func (nopBackend) CloseGPIO() error           { return nil }
+func (nopBackend) Output(pin int)             {}
+func (nopBackend) Input(pin int)              {}
+func (nopBackend) Write(pin int, high bool)   {}
+func (nopBackend) Read(pin int) bool          { return true }
+func (nopBackend) SetSpeed(hz int)            {}
+func (nopBackend) Transmit(data []byte) error { return nil }
@@ -0,0 +1,142 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// A frame with a horizontal gradient, so every pixel isn't the same.
+func benchmarkFrame() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	for y := 0; y < EPD_HEIGHT; y++ {
+		for x := 0; x < EPD_WIDTH; x++ {
+			img.Pix[img.PixOffset(x, y)] = uint8(x * 256 / EPD_WIDTH)
+		}
+	}
+
+	return img
+}
+
+// Hides the concrete type of an image, forcing the generic img.At path.
+type opaqueImage struct {
+	image.Image
+}
+
+func BenchmarkEncodeGray(b *testing.B) {
+	benchmarkEncodeImage(b, benchmarkFrame())
+}
+
+func BenchmarkEncodePaletted(b *testing.B) {
+	gray := benchmarkFrame()
+	palette := make(color.Palette, 256)
+	for i := range palette {
+		palette[i] = color.Gray{Y: uint8(i)}
+	}
+
+	img := image.NewPaletted(gray.Bounds(), palette)
+	copy(img.Pix, gray.Pix)
+	benchmarkEncodeImage(b, img)
+}
+
+// The path every image took before the fast paths, for comparison.
+func BenchmarkEncodeGeneric(b *testing.B) {
+	benchmarkEncodeImage(b, opaqueImage{benchmarkFrame()})
+}
+
+func benchmarkEncodeImage(b *testing.B, img image.Image) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EncodeImage(img, DEFAULT_THRESHOLD)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	img := image.NewRGBA(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	copyGray(img, benchmarkFrame())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.SetBytes(int64(ROW_SIZE * EPD_HEIGHT))
+	for i := 0; i < b.N; i++ {
+		EncodeImage(img, DEFAULT_THRESHOLD)
+	}
+}
+
+// Same as BenchmarkEncode reusing a single buffer, which shouldn't allocate it again.
+func BenchmarkEncodeInto(b *testing.B) {
+	img := image.NewRGBA(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	copyGray(img, benchmarkFrame())
+	dst := make([]byte, ROW_SIZE*EPD_HEIGHT)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.SetBytes(int64(ROW_SIZE * EPD_HEIGHT))
+	for i := 0; i < b.N; i++ {
+		if err := EncodeImageInto(dst, img, DEFAULT_THRESHOLD); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func copyGray(dst *image.RGBA, src *image.Gray) {
+	for i, y := range src.Pix {
+		dst.Pix[i*4], dst.Pix[i*4+1], dst.Pix[i*4+2], dst.Pix[i*4+3] = y, y, y, 0xFF
+	}
+}
+
+// Displays frames in a loop like an animation. The frame buffer is reused, so no 48KB
+// buffer should be allocated per frame once the first one was displayed.
+func BenchmarkDisplayImage(b *testing.B) {
+	e, err := NewDryRun()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := e.Init(); err != nil {
+		b.Fatal(err)
+	}
+
+	img := benchmarkFrame()
+	if err := e.DisplayImage(img); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := e.DisplayImage(img); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Decodes and displays a JPEG of the size of the screen, as DisplayFile does. Most of
+// what is allocated is the decoded image itself.
+func BenchmarkDisplayReaderJPEG(b *testing.B) {
+	// A color image, decoded as image.YCbCr like photos
+	img := image.NewRGBA(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	copyGray(img, benchmarkFrame())
+	var data bytes.Buffer
+	if err := jpeg.Encode(&data, img, nil); err != nil {
+		b.Fatal(err)
+	}
+
+	e, err := NewDryRun()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := e.Init(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := e.DisplayReader(bytes.NewReader(data.Bytes())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package waveshare7in5v2
+
+import "errors"
+
+var (
+	// Returned by Init when the display does not come out of reset.
+	ErrResetFailed = errors.New("display did not respond to reset")
+
+	// Returned by Init when the display does not release the busy line after POWER_ON.
+	ErrPowerOnTimeout = errors.New("timed out waiting for display to power on")
+
+	// Returned when the display doesn't release the busy line within the busy timeout,
+	// e.g. while refreshing, see WithBusyTimeout. Init reports ErrResetFailed or
+	// ErrPowerOnTimeout instead.
+	ErrBusyTimeout = errors.New("timed out waiting for display to become idle")
+
+	// Returned when a nil image is passed to a method displaying it.
+	ErrNilImage = errors.New("image is nil")
+
+	// Returned when an image without any pixel is passed to a method displaying it.
+	ErrEmptyImage = errors.New("image is empty")
+
+	// Returned when a raw buffer doesn't match the size of the display, see Config.
+	ErrBufferSize = errors.New("buffer size does not match the display")
+
+	// Returned by VerifyWiring when the display doesn't react to a command.
+	ErrNoDisplay = errors.New("display not detected")
+
+	// Returned when reading from the display with a Backend that doesn't implement
+	// Receiver, e.g. the default one without WithMISO.
+	ErrReadNotSupported = errors.New("backend cannot read from the display")
+)
@@ -0,0 +1,226 @@
+package waveshare7in5v2
+
+import "sync"
+
+// A command sent to the display followed by all the data sent after it.
+type Transfer struct {
+	Command byte
+	Data    []byte
+}
+
+// A Backend that records every command and data byte sent to the display instead of
+// talking to real hardware, allowing to test the exact SPI sequences produced by the
+// driver without a Raspberry Pi. It also simulates the panel, see Screen.
+//
+//	fake := waveshare7in5v2.NewFakeBackend()
+//	epd, _ := waveshare7in5v2.New(waveshare7in5v2.WithBackend(fake))
+//	epd.Init()
+//	fake.Commands() // []byte{0x01, 0x06, 0x04, ...}
+type FakeBackend struct {
+	// The pins the driver is configured with, used to tell commands from data
+	// and to simulate the busy line. Default to DEFAULT_DC_PIN and DEFAULT_BUSY_PIN.
+	DCPin   int
+	BusyPin int
+
+	mu        sync.Mutex
+	open      bool
+	gpioOpen  bool
+	speed     int
+	pins      map[int]bool
+	busy      []bool
+	transfers []Transfer
+	reads     []byte
+
+	// The panel rendering the transfers, see Screen. pending is set until the data of
+	// the last transfer was applied.
+	sim     panelSim
+	pending bool
+}
+
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		DCPin:   DEFAULT_DC_PIN,
+		BusyPin: DEFAULT_BUSY_PIN,
+
+		pins: map[int]bool{},
+	}
+}
+
+// Queues busy line states returned by the next reads of the busy pin, true meaning
+// the display is busy. Once the queue is exhausted the display is reported idle.
+func (f *FakeBackend) ScriptBusy(states ...bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.busy = append(f.busy, states...)
+}
+
+// Queues bytes returned by the next Receive calls. Once the queue is exhausted
+// zeros are returned.
+func (f *FakeBackend) ScriptRead(data ...byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reads = append(f.reads, data...)
+}
+
+// Returns every transfer recorded so far.
+func (f *FakeBackend) Transfers() []Transfer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	transfers := make([]Transfer, len(f.transfers))
+	copy(transfers, f.transfers)
+	return transfers
+}
+
+// Returns just the command bytes recorded so far, in order.
+func (f *FakeBackend) Commands() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	commands := make([]byte, len(f.transfers))
+	for i, t := range f.transfers {
+		commands[i] = t.Command
+	}
+	return commands
+}
+
+// Forgets all the transfers recorded so far.
+func (f *FakeBackend) ClearTransfers() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flush()
+	f.transfers = nil
+}
+
+// Returns whether the SPI device is currently open.
+func (f *FakeBackend) IsOpen() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.open
+}
+
+func (f *FakeBackend) Open() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.open = true
+	f.gpioOpen = true
+	return nil
+}
+
+func (f *FakeBackend) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.open = false
+	return nil
+}
+
+func (f *FakeBackend) CloseGPIO() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.gpioOpen = false
+	return nil
+}
+
+// Returns whether the GPIO device is currently open.
+func (f *FakeBackend) IsGPIOOpen() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.gpioOpen
+}
+
+// Returns the last SPI speed set by the driver.
+func (f *FakeBackend) Speed() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.speed
+}
+
+func (f *FakeBackend) SetSpeed(hz int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.speed = hz
+}
+
+func (f *FakeBackend) Output(pin int) {}
+
+func (f *FakeBackend) Input(pin int) {}
+
+func (f *FakeBackend) Write(pin int, high bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pins[pin] = high
+}
+
+func (f *FakeBackend) Read(pin int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if pin != f.BusyPin {
+		return f.pins[pin]
+	}
+
+	if len(f.busy) == 0 {
+		return true
+	}
+
+	busy := f.busy[0]
+	f.busy = f.busy[1:]
+
+	// The busy line is held low while the display is busy
+	return !busy
+}
+
+func (f *FakeBackend) Transmit(data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.pins[f.DCPin] {
+		for _, cmd := range data {
+			// A command ends the data of the previous one
+			f.flush()
+			f.transfers = append(f.transfers, Transfer{Command: cmd})
+			f.pending = true
+
+			if cmd == DISPLAY_REFRESH {
+				f.flush()
+			}
+		}
+		return nil
+	}
+
+	if len(f.transfers) == 0 {
+		f.transfers = append(f.transfers, Transfer{})
+	}
+
+	last := &f.transfers[len(f.transfers)-1]
+	last.Data = append(last.Data, data...)
+	return nil
+}
+
+func (f *FakeBackend) Receive(n int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data := make([]byte, n)
+	f.reads = f.reads[copy(data, f.reads):]
+	return data, nil
+}
+
+// Applies the last transfer to the simulated panel if it wasn't yet.
+func (f *FakeBackend) flush() {
+	if f.pending && len(f.transfers) > 0 {
+		f.sim.apply(f.transfers[len(f.transfers)-1])
+	}
+	f.pending = false
+}
@@ -0,0 +1,90 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+	"time"
+)
+
+// Powers on the screen using the fast refresh waveform, which roughly halves the
+// refresh time of Init at the cost of a bit of contrast and more ghosting. Useful
+// for interactive kiosks, use Init for the best quality.
+func (e *Epd) InitFast() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.initFast()
+}
+
+func (e *Epd) initFast() error {
+	return e.retryInit(e.initFastOnce)
+}
+
+func (e *Epd) initFastOnce() error {
+	e.debug("Initializing display for fast refresh")
+	if err := e.reset(); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(PANEL_SETTING, []byte{
+		0x1F, // KW-3f  KWR-2F	BWROTP 0f	BWOTP 1f
+	}); err != nil {
+		return err
+	}
+
+	if err := e.sendVCOMDataInterval(modeFast); err != nil {
+		return err
+	}
+
+	if err := e.sendCommand(POWER_ON); err != nil {
+		return err
+	}
+	e.wait(100 * time.Millisecond)
+	if err := e.waitUntilIdle(); err != nil {
+		return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+	}
+
+	if err := e.sendCommandWithData(BOOSTER_SOFT_START, []byte{ // enhanced display drive
+		0x27,
+		0x27,
+		0x18,
+		0x17,
+	}); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(CASCADE_SETTING, []byte{0x02}); err != nil {
+		return err
+	}
+	// selects the fast refresh waveform
+	if err := e.sendCommandWithData(FORCE_TEMPERATURE, []byte{0x5A}); err != nil {
+		return err
+	}
+
+	e.asleep = false
+	e.poweredOff = false
+	e.mode = modeFast
+	e.debug("Display initialized for fast refresh")
+	return nil
+}
+
+// Same as DisplayImage but using the fast refresh waveform. Runs InitFast first if the
+// display was initialized in another mode.
+func (e *Epd) DisplayImageFast(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.mode != modeFast {
+		if err := e.initFast(); err != nil {
+			return err
+		}
+	}
+
+	return e.withRecovery(func() error {
+		return e.displayImageThreshold(img, e.threshold)
+	})
+}
@@ -0,0 +1,83 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	_ "golang.org/x/image/bmp"
+)
+
+// Decodes the image file at path and displays it scaled with FitContain. The format is
+// detected from the content of the file: PNG, JPEG, GIF (first frame) and BMP work out
+// of the box, the driver registers the decoders. Other formats need their decoder to be
+// imported, e.g. _ "golang.org/x/image/webp".
+func (e *Epd) DisplayFile(path string) error {
+	return e.displayFile(path, "")
+}
+
+// Same as DisplayFile but fails if the file isn't a PNG.
+func (e *Epd) DisplayPNG(path string) error {
+	return e.displayFile(path, "png")
+}
+
+// Same as DisplayFile but fails if the file isn't a JPEG.
+func (e *Epd) DisplayJPEG(path string) error {
+	return e.displayFile(path, "jpeg")
+}
+
+// Same as DisplayFile but decodes the image from r, e.g. an HTTP request body or an
+// embedded asset.
+func (e *Epd) DisplayReader(r io.Reader) error {
+	return e.displayReader(r, "image", "")
+}
+
+// Same as DisplayFile but opens name from fsys, e.g. an embed.FS holding the logos and
+// splash screens of a single binary deployment.
+func (e *Epd) DisplayFromFS(fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return e.displayReader(f, name, "")
+}
+
+func (e *Epd) displayFile(path string, format string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return e.displayReader(f, path, format)
+}
+
+// Decodes an image from r and displays it, name is used in errors.
+func (e *Epd) displayReader(r io.Reader, name string, format string) error {
+	img, decoded, err := image.Decode(r)
+	if errors.Is(err, image.ErrFormat) {
+		hint := "supported formats are PNG, JPEG, GIF and BMP"
+		if ext := filepath.Ext(name); ext != "" {
+			hint = fmt.Sprintf("%s files are not supported, %s", ext, hint)
+		}
+		return fmt.Errorf("failed to decode %s: %w, %s", name, err, hint)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	if format != "" && decoded != format {
+		return fmt.Errorf("failed to decode %s: expected %s, got %s", name, format, decoded)
+	}
+
+	return e.DisplayImageFit(img, FitContain)
+}
@@ -0,0 +1,40 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"image"
+)
+
+// Transforms an image before it is converted to black and white, e.g. to sharpen it,
+// detect edges or apply a custom halftone.
+type ImageFilter func(image.Image) image.Image
+
+// Runs filter on every image before it is converted to black and white, by DisplayImage
+// and the methods based on it as well as when a Canvas is refreshed or flushed. The
+// filter receives an image with its top left corner at the origin and must return one
+// with the size of the screen, see Bounds, anything outside of it is not displayed
+// and missing pixels stay white. No filter is applied by default.
+//
+// Images drawn into a region of the screen aren't filtered, since a filter may look at
+// pixels outside of it: DisplayPartial and its variants, DisplayTile, Batch,
+// DisplayProgress and DisplayChart encode their image as is, so those updates look
+// different from DisplayImage when a filter is set. Filter such images beforehand.
+func WithImageFilter(filter ImageFilter) Option {
+	return func(e *Epd) error {
+		if filter == nil {
+			return errors.New("image filter must not be nil")
+		}
+
+		e.filter = filter
+		return nil
+	}
+}
+
+// Runs the image filter on img, if any.
+func (e *Epd) applyFilter(img image.Image) image.Image {
+	if e.filter == nil {
+		return img
+	}
+
+	return atOrigin(e.filter(img))
+}
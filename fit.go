@@ -0,0 +1,248 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// How an image is scaled to the screen by DisplayImageFit.
+type FitMode int
+
+const (
+	// Scales the image to the screen size, ignoring its aspect ratio.
+	FitStretch FitMode = iota
+	// Scales the image to fit inside the screen, padding the rest (letterbox).
+	FitContain
+	// Scales the image to fill the screen, cropping whatever overflows.
+	FitCover
+)
+
+// Sets the color transparent pixels are composited over before being converted to
+// black and white, so transparent PNGs get a clean background. Defaults to white.
+func WithBackground(c color.Color) Option {
+	return func(e *Epd) error {
+		if c == nil {
+			return errors.New("background color must not be nil")
+		}
+
+		e.background = c
+		return nil
+	}
+}
+
+// Sets the color used to pad images scaled with FitContain. Defaults to white.
+func WithLetterboxColor(c color.Color) Option {
+	return func(e *Epd) error {
+		if c == nil {
+			return errors.New("letterbox color must not be nil")
+		}
+
+		e.letterbox = c
+		return nil
+	}
+}
+
+// Scales img to the screen using mode and displays it.
+func (e *Epd) DisplayImageFit(img image.Image, mode FitMode) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Images decoded at the size of the screen are encoded as they are, saving the
+	// memory and time of a resampled copy
+	size := e.logicalBounds().Size()
+	if img.Bounds().Size() != size {
+		img = Fit(img, size, mode, e.letterbox)
+	}
+
+	return e.withRecovery(func() error {
+		return e.displayImageThreshold(img, e.threshold)
+	})
+}
+
+// Displays img centered on a mat of matColor, like a framed photo: the image is scaled
+// to fit inside the screen minus matWidth pixels on every side, keeping its aspect
+// ratio, and the rest of the screen is filled with matColor.
+func (e *Epd) DisplayImageMatted(img image.Image, matWidth int, matColor color.Color) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if matColor == nil {
+		return errors.New("mat color must not be nil")
+	}
+
+	screen := e.logicalBounds()
+	if matWidth < 0 || 2*matWidth >= screen.Dx() || 2*matWidth >= screen.Dy() {
+		return fmt.Errorf("mat width %d must not be negative and leave room for the image", matWidth)
+	}
+	inner := screen.Inset(matWidth)
+
+	frame := image.NewRGBA(screen)
+	draw.Draw(frame, screen, image.NewUniform(matColor), image.Point{}, draw.Src)
+	draw.Draw(frame, inner, Fit(img, inner.Size(), FitContain, matColor), image.Point{}, draw.Src)
+
+	return e.withRecovery(func() error {
+		return e.displayImageThreshold(frame, e.threshold)
+	})
+}
+
+// Scales img to size using bilinear resampling. With FitContain the uncovered area
+// is filled with background.
+func Fit(img image.Image, size image.Point, mode FitMode, background color.Color) *image.RGBA {
+	out := image.NewRGBA(image.Rectangle{Max: size})
+	src := img.Bounds()
+	if src.Empty() || size.X <= 0 || size.Y <= 0 {
+		return out
+	}
+
+	// Where the scaled image lands on the output
+	dst := out.Bounds()
+	if mode != FitStretch {
+		sx := float64(size.X) / float64(src.Dx())
+		sy := float64(size.Y) / float64(src.Dy())
+
+		scale := math.Min(sx, sy)
+		if mode == FitCover {
+			scale = math.Max(sx, sy)
+		}
+
+		w := int(math.Round(float64(src.Dx()) * scale))
+		h := int(math.Round(float64(src.Dy()) * scale))
+		dst = image.Rect(0, 0, w, h).Add(image.Pt((size.X-w)/2, (size.Y-h)/2))
+	}
+
+	bg := color.RGBAModel.Convert(background).(color.RGBA)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if !(image.Point{x, y}.In(dst)) {
+				out.SetRGBA(x, y, bg)
+				continue
+			}
+
+			// Center of the output pixel mapped into the source image
+			u := (float64(x-dst.Min.X)+0.5)*float64(src.Dx())/float64(dst.Dx()) - 0.5
+			v := (float64(y-dst.Min.Y)+0.5)*float64(src.Dy())/float64(dst.Dy()) - 0.5
+			out.SetRGBA(x, y, bilinear(img, src, u, v))
+		}
+	}
+
+	return out
+}
+
+// Scales img to a physical size in inches on a screen with the given pixel density,
+// e.g. PANEL_DPI, keeping its aspect ratio. Passing 0 for width or height derives it
+// from the other one, otherwise the image is made as large as possible within both.
+// The result is meant to be placed with DisplayImageAt or Composite.
+func ScaleToInches(img image.Image, width, height, dpi float64) *image.RGBA {
+	src := img.Bounds()
+	if src.Empty() || dpi <= 0 || (width <= 0 && height <= 0) {
+		return image.NewRGBA(image.Rectangle{})
+	}
+
+	scale := math.Inf(1)
+	if width > 0 {
+		scale = width * dpi / float64(src.Dx())
+	}
+	if height > 0 {
+		scale = math.Min(scale, height*dpi/float64(src.Dy()))
+	}
+
+	size := image.Pt(
+		int(math.Round(float64(src.Dx())*scale)),
+		int(math.Round(float64(src.Dy())*scale)),
+	)
+	return Fit(img, size, FitStretch, color.White)
+}
+
+// Rotates img by degrees clockwise around its center using bilinear sampling, e.g. for
+// text blocks at an angle or panels mounted askew. Unlike WithRotation it works on the
+// content and supports any angle: the result grows to hold the whole rotated image and
+// the corners it uncovers are filled with background.
+func RotateImage(img image.Image, degrees float64, background color.Color) *image.RGBA {
+	src := img.Bounds()
+	if src.Empty() {
+		return image.NewRGBA(image.Rectangle{})
+	}
+
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	w, h := float64(src.Dx()), float64(src.Dy())
+	// The epsilon keeps right angles from gaining a pixel out of the float error
+	size := image.Pt(
+		int(math.Ceil(math.Abs(w*cos)+math.Abs(h*sin)-1e-9)),
+		int(math.Ceil(math.Abs(w*sin)+math.Abs(h*cos)-1e-9)),
+	)
+	out := image.NewRGBA(image.Rectangle{Max: size})
+
+	bg := color.RGBAModel.Convert(background).(color.RGBA)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			// Center of the output pixel relative to the center of the output, rotated
+			// back into the source image
+			dx := float64(x) + 0.5 - float64(size.X)/2
+			dy := float64(y) + 0.5 - float64(size.Y)/2
+			u := dx*cos + dy*sin + w/2 - 0.5
+			v := -dx*sin + dy*cos + h/2 - 0.5
+
+			if u < -0.5 || v < -0.5 || u > w-0.5 || v > h-0.5 {
+				out.SetRGBA(x, y, bg)
+				continue
+			}
+			out.SetRGBA(x, y, bilinear(img, src, u, v))
+		}
+	}
+
+	return out
+}
+
+// Samples img at the fractional position u, v relative to src.Min.
+func bilinear(img image.Image, src image.Rectangle, u, v float64) color.RGBA {
+	x0 := int(math.Floor(u))
+	y0 := int(math.Floor(v))
+	fx := u - float64(x0)
+	fy := v - float64(y0)
+
+	var sum [4]float64
+	for _, p := range []struct {
+		x, y   int
+		weight float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x0 + 1, y0, fx * (1 - fy)},
+		{x0, y0 + 1, (1 - fx) * fy},
+		{x0 + 1, y0 + 1, fx * fy},
+	} {
+		r, g, b, a := img.At(src.Min.X+clamp(p.x, 0, src.Dx()-1), src.Min.Y+clamp(p.y, 0, src.Dy()-1)).RGBA()
+		sum[0] += float64(r) * p.weight
+		sum[1] += float64(g) * p.weight
+		sum[2] += float64(b) * p.weight
+		sum[3] += float64(a) * p.weight
+	}
+
+	return color.RGBA{
+		R: uint8(sum[0] / 257),
+		G: uint8(sum[1] / 257),
+		B: uint8(sum[2] / 257),
+		A: uint8(sum[3] / 257),
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,63 @@
+package waveshare7in5v2
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+)
+
+// Maximum number of flashes in a single Flash call, every flash being two partial
+// refreshes that wear the panel and build up ghosting.
+const MAX_FLASHES = 10
+
+// Draws attention to the region r, e.g. a notification area, by inverting it and
+// restoring it times times with partial refreshes interval apart. The region is
+// restored from the last frame afterwards, also when inverting it fails. times is
+// limited to MAX_FLASHES. Requires InitPartial and a frame displayed before, like InvertRegion.
+func (e *Epd) Flash(r image.Rectangle, times int, interval time.Duration) error {
+	if times < 1 || times > MAX_FLASHES {
+		return fmt.Errorf("flash count %d must be between 1 and %d", times, MAX_FLASHES)
+	}
+	if interval < 0 {
+		return fmt.Errorf("flash interval %v must not be negative", interval)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	aligned, original, inverted, err := e.invertedRegion(r)
+	if err != nil {
+		return err
+	}
+
+	e.debug("Flashing region")
+	if err := e.withRecovery(func() error {
+		ctx := context.Background()
+		for i := 0; i < times; i++ {
+			if i > 0 {
+				e.wait(interval)
+			}
+
+			if err := e.sendPartial(ctx, inverted, aligned); err != nil {
+				// Best effort, the region may be left inverted otherwise
+				if restoreErr := e.sendPartial(ctx, original, aligned); restoreErr != nil {
+					e.warn("Cannot restore flashed region:", restoreErr)
+				}
+				return err
+			}
+			e.wait(interval)
+
+			if err := e.sendPartial(ctx, original, aligned); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	e.debug("Region flashed")
+	return nil
+}
@@ -0,0 +1,83 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+)
+
+// A set of named frames encoded once, for UIs switching between a fixed set of screens
+// like menus or status pages. Showing a frame only pushes its buffer to the display,
+// without converting the image again. Frames are encoded with the rotation, threshold
+// and filter of the display at the time they are added, add them again after changing
+// those. Safe for concurrent use.
+type FrameCache struct {
+	epd    *Epd
+	mu     sync.Mutex
+	frames map[string][]byte
+}
+
+// Creates an empty cache of frames shown on e.
+func NewFrameCache(e *Epd) *FrameCache {
+	return &FrameCache{epd: e, frames: map[string][]byte{}}
+}
+
+// Encodes img like DisplayImage and stores it as name, replacing the frame already
+// stored under that name.
+func (c *FrameCache) Add(name string, img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e := c.epd
+	e.mu.Lock()
+	buffer := e.getBuffer(img, e.threshold)
+	e.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.frames[name] = buffer
+	return nil
+}
+
+// Displays the frame stored as name with a full refresh, like DisplayBuffer.
+func (c *FrameCache) Show(name string) error {
+	c.mu.Lock()
+	buffer, ok := c.frames[name]
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no frame named %q", name)
+	}
+
+	e := c.epd
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.display(buffer)
+	})
+}
+
+// Forgets the frame stored as name, if any.
+func (c *FrameCache) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.frames, name)
+}
+
+// Returns the names of the stored frames, sorted.
+func (c *FrameCache) Names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.frames))
+	for name := range c.frames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -3,3 +3,7 @@ module github.com/NelsonBrandao/waveshare7in5v2
 go 1.19
 
 require github.com/stianeikeland/go-rpio/v4 v4.6.0
+
+require golang.org/x/image v0.10.0
+
+require rsc.io/qr v0.2.0
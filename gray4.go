@@ -0,0 +1,206 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+)
+
+// Luminances from which pixels become dark gray, light gray and white in grayscale,
+// splitting the range in 4 equally sized buckets. See WithGray4Levels.
+var defaultGray4Levels = [3]uint8{64, 128, 192}
+
+// Powers on the screen using the register setup for 4 level grayscale, which selects
+// the grayscale waveform stored in the panel. Must be used instead of Init before
+// calling DisplayImageGray4. Run Init again to go back to the faster black and white mode.
+func (e *Epd) InitGray4() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.initGray4()
+}
+
+func (e *Epd) initGray4() error {
+	return e.retryInit(e.initGray4Once)
+}
+
+func (e *Epd) initGray4Once() error {
+	e.debug("Initializing display for grayscale")
+	if err := e.reset(); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(PANEL_SETTING, []byte{
+		0x1F, // KW-3f  KWR-2F	BWROTP 0f	BWOTP 1f
+	}); err != nil {
+		return err
+	}
+
+	if err := e.sendVCOMDataInterval(modeGray4); err != nil {
+		return err
+	}
+
+	if err := e.sendCommand(POWER_ON); err != nil {
+		return err
+	}
+	e.wait(100 * time.Millisecond)
+	if err := e.waitUntilIdle(); err != nil {
+		return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+	}
+
+	if err := e.sendCommandWithData(BOOSTER_SOFT_START, []byte{ // enhanced display drive
+		0x27,
+		0x27,
+		0x18,
+		0x17,
+	}); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(CASCADE_SETTING, []byte{0x02}); err != nil {
+		return err
+	}
+	// selects the grayscale waveform
+	if err := e.sendCommandWithData(FORCE_TEMPERATURE, []byte{0x5F}); err != nil {
+		return err
+	}
+
+	e.asleep = false
+	e.poweredOff = false
+	e.mode = modeGray4
+	e.debug("Display initialized for grayscale")
+	return nil
+}
+
+// Displays img using 4 gray levels (black, dark gray, light gray and white), which looks
+// much better than pure black and white for photos and antialiased text.
+// A grayscale refresh is noticeably slower than DisplayImage. Requires InitGray4 to be
+// called first.
+func (e *Epd) DisplayImageGray4(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.displayImageGray4(img)
+	})
+}
+
+func (e *Epd) displayImageGray4(img image.Image) error {
+	e.debug("Displaying grayscale image")
+	oldPlane, newPlane := e.getGray4Buffers(atOrigin(img))
+
+	// The frame can no longer be represented in black and white
+	e.lastBuffer = nil
+	e.lastHash = 0
+	e.frameValid = false
+
+	if err := e.sendCommandWithData(DISPLAY_START_TRANSMISSION_1, oldPlane); err != nil {
+		return err
+	}
+	if err := e.sendCommandWithData(DISPLAY_START_TRANSMISSION_2, newPlane); err != nil {
+		return err
+	}
+
+	if err := e.turnOnDisplay(); err != nil {
+		return err
+	}
+	e.debug("Grayscale image displayed")
+	return nil
+}
+
+// Applies a gamma curve to the luminance before mapping it to the 4 gray levels,
+// out = in^(1/gamma) like EncodeImageGamma. The levels of the panel aren't spaced
+// evenly to the eye, a gamma above 1 lifts the mid-tones of photos out of the dark
+// levels and below 1 pushes them down. Defaults to 1, no correction.
+func WithGray4Gamma(gamma float64) Option {
+	return func(e *Epd) error {
+		if gamma <= 0 || math.IsInf(gamma, 0) || math.IsNaN(gamma) {
+			return fmt.Errorf("gray4 gamma %v must be a positive number", gamma)
+		}
+
+		e.gray4Gamma = gamma
+		return nil
+	}
+}
+
+// Sets the luminances, after the gamma curve, from which pixels are displayed as dark
+// gray, light gray and white by DisplayImageGray4. Darker pixels are black. The levels
+// must be increasing. Defaults to 64, 128 and 192, 4 equally sized buckets.
+func WithGray4Levels(dark, light, white uint8) Option {
+	return func(e *Epd) error {
+		if dark == 0 || dark >= light || light >= white {
+			return fmt.Errorf("gray4 levels %d, %d and %d must be increasing and above 0", dark, light, white)
+		}
+
+		e.grayLevels = [3]uint8{dark, light, white}
+		return nil
+	}
+}
+
+// Converts an image into the two bit planes the panel expects for grayscale.
+// Each pixel is mapped to a level from 0 (black) to 3 (white), the low bit of the
+// level goes into the old data plane and the high bit into the new data plane.
+func (e *Epd) getGray4Buffers(img image.Image) ([]byte, []byte) {
+	oldPlane := make([]byte, e.bufferSize)
+	newPlane := make([]byte, e.bufferSize)
+	imgBounds := img.Bounds()
+	levels := e.gray4Table()
+
+	for y := 0; y < e.bounds.Dy(); y++ {
+		for x := 0; x < e.bounds.Dx(); x += PIXEL_SIZE {
+			var oldPixel, newPixel byte
+
+			for px := 0; px < PIXEL_SIZE; px++ {
+				// Pixels outside of the image or the panel are white
+				var level uint8 = 3
+				if x+px < e.bounds.Max.X {
+					if lx, ly := e.toLogical(x+px, y); image.Pt(lx, ly).In(imgBounds) {
+						level = levels[Luminance(flatten(img.At(lx, ly), e.background))]
+					}
+				}
+
+				if level&0x01 != 0 {
+					oldPixel |= (0x80 >> px)
+				}
+				if level&0x02 != 0 {
+					newPixel |= (0x80 >> px)
+				}
+			}
+
+			oldPlane[y*e.pixelWidth+x/PIXEL_SIZE] = oldPixel
+			newPlane[y*e.pixelWidth+x/PIXEL_SIZE] = newPixel
+		}
+	}
+
+	return oldPlane, newPlane
+}
+
+// Returns the gray level, 0 being black, of each luminance with the gamma curve and
+// the levels set by WithGray4Gamma and WithGray4Levels.
+func (e *Epd) gray4Table() [256]uint8 {
+	gamma := e.gray4Gamma
+	if gamma <= 0 {
+		gamma = 1
+	}
+	thresholds := e.grayLevels
+	if thresholds == [3]uint8{} {
+		thresholds = defaultGray4Levels
+	}
+
+	var table [256]uint8
+	for lum := range table {
+		corrected := uint8(math.Round(255 * math.Pow(float64(lum)/255, 1/gamma)))
+		for _, threshold := range thresholds {
+			if corrected >= threshold {
+				table[lum]++
+			}
+		}
+	}
+
+	return table
+}
@@ -0,0 +1,68 @@
+package waveshare7in5v2
+
+import (
+	"hash/fnv"
+	"image"
+)
+
+// Same as DisplayImageIfChanged but compares a 64-bit FNV-1a hash of the encoded frame
+// with the hash of the last frame instead of the frames themselves, see LastHash.
+// Returns whether the display was refreshed.
+func (e *Epd) DisplayImageHashed(img image.Image) (bool, error) {
+	if err := checkImage(img); err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buffer := e.getBuffer(img, e.threshold)
+	defer e.releaseBuffer(buffer)
+	hash := hashBuffer(buffer)
+	if hash == e.currentHash() {
+		e.debug("Image unchanged")
+		return false, nil
+	}
+
+	if err := e.withRecovery(func() error {
+		return e.display(buffer)
+	}); err != nil {
+		return false, err
+	}
+	e.lastHash = hash
+	return true, nil
+}
+
+// Returns the hash of the last frame sent to the display as compared by
+// DisplayImageHashed, or 0 if it is unknown, e.g. after InitGray4.
+// It can be persisted and passed to SetLastHash to skip the first refresh after a
+// restart when the panel still shows the same frame.
+func (e *Epd) LastHash() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.currentHash()
+}
+
+// Sets the hash of the frame on screen, as returned by LastHash before a restart.
+func (e *Epd) SetLastHash(hash uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastHash = hash
+}
+
+// The hash is only computed when needed, and cleared whenever the remembered frame changes.
+func (e *Epd) currentHash() uint64 {
+	if e.lastHash == 0 && e.lastBuffer != nil {
+		e.lastHash = hashBuffer(e.lastBuffer)
+	}
+
+	return e.lastHash
+}
+
+func hashBuffer(buffer []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(buffer)
+	return h.Sum64()
+}
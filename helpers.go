@@ -1,19 +1,93 @@
 package waveshare7in5v2
 
 import (
+	"image"
 	"image/color"
+	"reflect"
 	"time"
 )
 
+// Returns the perceived brightness of c using the Rec. 601 weights
+// (0.299R + 0.587G + 0.114B), from 0 (black) to 255 (white).
+func Luminance(c color.Color) uint8 {
+	r, g, b, _ := c.RGBA()
+	return luminance(r, g, b)
+}
+
+// Same as Luminance but takes the 16 bit components returned by color.Color.RGBA.
+func luminance(r, g, b uint32) uint8 {
+	// Same fixed point weights as color.GrayModel, rounded
+	y := (19595*r + 38470*g + 7471*b + 1<<15) >> 24
+	return uint8(y)
+}
+
+// Returns ErrNilImage or ErrEmptyImage if img can't be displayed, rather than letting
+// it panic further down, including for nil pointers of a concrete image type.
+func checkImage(img image.Image) error {
+	if img == nil {
+		return ErrNilImage
+	}
+	if v := reflect.ValueOf(img); v.Kind() == reflect.Pointer && v.IsNil() {
+		return ErrNilImage
+	}
+	if img.Bounds().Empty() {
+		return ErrEmptyImage
+	}
+
+	return nil
+}
+
+// Moves the top left corner of img to (0, 0) so images that don't start at the
+// origin, e.g. created with SubImage, are displayed from their first pixel.
+func atOrigin(img image.Image) image.Image {
+	min := img.Bounds().Min
+	if min == (image.Point{}) {
+		return img
+	}
+
+	return &translatedImage{img, min}
+}
+
+type translatedImage struct {
+	image.Image
+	offset image.Point
+}
+
+func (t *translatedImage) Bounds() image.Rectangle {
+	return t.Image.Bounds().Sub(t.offset)
+}
+
+func (t *translatedImage) At(x, y int) color.Color {
+	return t.Image.At(x+t.offset.X, y+t.offset.Y)
+}
+
+// Composites c over the opaque color bg, so transparent pixels take the color of bg
+// instead of reading as black.
+func flatten(c color.Color, bg color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	if a == 0xffff {
+		return c
+	}
+
+	// RGBA values are alpha premultiplied
+	br, bgG, bb, _ := bg.RGBA()
+	return color.RGBA64{
+		R: uint16(r + br*(0xffff-a)/0xffff),
+		G: uint16(g + bgG*(0xffff-a)/0xffff),
+		B: uint16(b + bb*(0xffff-a)/0xffff),
+		A: 0xffff,
+	}
+}
+
 func isBlack(c color.Color, threshold uint8) bool {
-	return color.GrayModel.Convert(c).(color.Gray).Y < threshold
+	return Luminance(c) < threshold
 }
 
-func splitInChunks(data []byte) [][]byte {
+func splitInChunks(data []byte, size int) [][]byte {
 	var chunks [][]byte
 
-	for i := 0; i < len(data); i += MAX_CHUNK_SIZE {
-		end := i + MAX_CHUNK_SIZE
+	for i := 0; i < len(data); i += size {
+		end := i + size
 
 		if end > len(data) {
 			end = len(data)
@@ -25,6 +99,7 @@ func splitInChunks(data []byte) [][]byte {
 	return chunks
 }
 
-func wait(d time.Duration) {
-	time.Sleep(d * time.Millisecond)
+// Blocks for d using the function set with WithSleepFunc.
+func (e *Epd) wait(d time.Duration) {
+	e.sleepFunc(d)
 }
@@ -0,0 +1,35 @@
+package waveshare7in5v2
+
+import (
+	"testing"
+	"time"
+)
+
+// Creates a driver talking to a FakeBackend that never waits.
+func newTestEpd(t *testing.T, opts ...Option) (*Epd, *FakeBackend) {
+	t.Helper()
+
+	fake := NewFakeBackend()
+	opts = append([]Option{WithBackend(fake), WithSleepFunc(func(time.Duration) {})}, opts...)
+	e, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e, fake
+}
+
+// Returns the data of the last transfer with cmd, failing if there is none.
+func lastTransfer(t *testing.T, fake *FakeBackend, cmd byte) []byte {
+	t.Helper()
+
+	transfers := fake.Transfers()
+	for i := len(transfers) - 1; i >= 0; i-- {
+		if transfers[i].Command == cmd {
+			return transfers[i].Data
+		}
+	}
+
+	t.Fatalf("command 0x%02x was not sent", cmd)
+	return nil
+}
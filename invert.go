@@ -0,0 +1,26 @@
+package waveshare7in5v2
+
+// Swaps black and white on the screen, e.g. for white on black dashboards.
+func WithInverted(inverted bool) Option {
+	return func(e *Epd) error {
+		e.SetInverted(inverted)
+		return nil
+	}
+}
+
+// Swaps black and white on the screen. Takes effect on the next image sent to the
+// display, while the border color follows on the next Init.
+func (e *Epd) SetInverted(inverted bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.inverted = inverted
+}
+
+// Returns whether black and white are swapped.
+func (e *Epd) Inverted() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.inverted
+}
@@ -0,0 +1,74 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+// The Logger used by the driver to report what it is doing. *log.Logger satisfies it,
+// other loggers such as *slog.Logger can be plugged in with LoggerFunc.
+type Logger interface {
+	Println(v ...any)
+}
+
+// Adapts a plain function into a Logger, e.g.
+//
+//	LoggerFunc(func(v ...any) { slog.Debug(fmt.Sprint(v...)) })
+type LoggerFunc func(v ...any)
+
+func (f LoggerFunc) Println(v ...any) {
+	f(v...)
+}
+
+// A Logger that discards everything, used to silence the driver.
+var DiscardLogger Logger = log.New(io.Discard, "", 0)
+
+// How much the driver logs, see WithVerbosity.
+type LogLevel int
+
+const (
+	// Only logs the problems the driver worked around, e.g. retried transfers or a
+	// display recovered from being stuck busy. This is the default.
+	LogWarn LogLevel = iota
+	// Also logs every operation as it starts and completes, e.g. "Displaying image",
+	// which is handy to trace a misbehaving display but repetitive in production.
+	LogDebug
+)
+
+// Sets how much the driver logs. Defaults to LogWarn, use LogDebug for the full trace of
+// every operation, printed by default by earlier versions.
+func WithVerbosity(level LogLevel) Option {
+	return func(e *Epd) error {
+		if level != LogWarn && level != LogDebug {
+			return fmt.Errorf("unknown log level %d", level)
+		}
+
+		e.verbosity = level
+		return nil
+	}
+}
+
+// Routes the driver logs to l instead of the standard logger. Passing nil silences them.
+func WithLogger(l Logger) Option {
+	return func(e *Epd) error {
+		if l == nil {
+			l = DiscardLogger
+		}
+
+		e.logger = l
+		return nil
+	}
+}
+
+// Logs a warning about a problem the driver worked around.
+func (e *Epd) warn(v ...any) {
+	e.logger.Println(v...)
+}
+
+// Logs the progress of an operation when the verbosity is LogDebug.
+func (e *Epd) debug(v ...any) {
+	if e.verbosity >= LogDebug {
+		e.logger.Println(v...)
+	}
+}
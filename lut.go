@@ -0,0 +1,58 @@
+package waveshare7in5v2
+
+import "fmt"
+
+// Custom waveform lookup tables, each LUT_SIZE bytes long. WW, BW, WB and BB drive the
+// pixels going from white to white, black to white, white to black and black to black.
+type LUTSet struct {
+	VCOM []byte
+	WW   []byte
+	BW   []byte
+	WB   []byte
+	BB   []byte
+}
+
+// Replaces the built-in waveforms of the display with lut, used by every refresh until
+// the display is initialized again with Init or any other init method.
+//
+// Badly tuned tables can produce faint or ghosted images but won't damage the panel.
+func (e *Epd) SetLUT(lut LUTSet) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	tables := []struct {
+		name string
+		cmd  byte
+		data []byte
+	}{
+		{"VCOM", LUT_VCOM, lut.VCOM},
+		{"WW", LUT_WW, lut.WW},
+		{"BW", LUT_BW, lut.BW},
+		{"WB", LUT_WB, lut.WB},
+		{"BB", LUT_BB, lut.BB},
+	}
+
+	for _, t := range tables {
+		if len(t.data) != LUT_SIZE {
+			return fmt.Errorf("%s LUT must be %d bytes, got %d", t.name, LUT_SIZE, len(t.data))
+		}
+	}
+
+	if err := e.wake(); err != nil {
+		return err
+	}
+
+	e.debug("Loading custom LUT")
+	// Same as Init but reading the LUT from registers instead of OTP
+	if err := e.sendCommandWithData(PANEL_SETTING, []byte{0x3F}); err != nil {
+		return err
+	}
+
+	for _, t := range tables {
+		if err := e.sendCommandWithData(t.cmd, t.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+)
+
+// The refresh waveform and register setup used by Display, see SetMode.
+type Mode int
+
+const (
+	// Flashes the whole panel a few times, giving the best contrast and no ghosting
+	// at the cost of the slowest refresh. What Init sets up.
+	ModeFull = Mode(modeFull)
+	// Roughly halves the refresh time of ModeFull at the cost of a bit of contrast and
+	// more ghosting. What InitFast sets up.
+	ModeFast = Mode(modeFast)
+	// Updates without flashing, much faster but ghosting builds up until a full refresh,
+	// see WithFullRefreshInterval. What InitPartial sets up.
+	ModePartial = Mode(modePartial)
+	// Shows 4 gray levels, best for photos and antialiased text but the slowest of all.
+	// What InitGray4 sets up.
+	ModeGray4 = Mode(modeGray4)
+)
+
+func (m Mode) String() string {
+	if name, ok := modeNames[initMode(m)]; ok {
+		return name
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Initializes the display in mode, same as calling the matching Init, InitFast,
+// InitPartial or InitGray4. Display then refreshes the screen using that mode.
+func (e *Epd) SetMode(mode Mode) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.robust = false
+	switch mode {
+	case ModeFull:
+		return e.initFullWarm()
+	case ModeFast:
+		return e.initFast()
+	case ModePartial:
+		return e.initPartial()
+	case ModeGray4:
+		return e.initGray4()
+	default:
+		return fmt.Errorf("invalid mode %d", int(mode))
+	}
+}
+
+// Returns the mode the display was last initialized with, 0 ("none") before the first init.
+func (e *Epd) Mode() Mode {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return Mode(e.mode)
+}
+
+// Displays img using the current mode, see SetMode. In ModePartial the whole screen is
+// refreshed without flashing, the first frame after the init becomes the base image.
+// Runs Init first if the display was never initialized.
+func (e *Epd) Display(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.mode == modeNone {
+		if err := e.initFullWarm(); err != nil {
+			return err
+		}
+	}
+
+	return e.withRecovery(func() error {
+		switch e.mode {
+		case modePartial:
+			if e.lastBuffer == nil {
+				return e.setBaseImage(img)
+			}
+			return e.displayPartial(img, e.logicalBounds())
+		case modeGray4:
+			return e.displayImageGray4(img)
+		default:
+			return e.displayImageThreshold(img, e.threshold)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+)
+
+// Displays a two color image the size of Bounds() without any thresholding, the
+// darkest palette entry is shown as black and the other as white. This is the fastest
+// way to show pre-rendered images.
+func (e *Epd) DisplayMono(img *image.Paletted) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(img.Palette) != 2 {
+		return fmt.Errorf("mono image must have 2 colors, got %d", len(img.Palette))
+	}
+	if size := e.logicalBounds().Size(); img.Bounds().Size() != size {
+		return fmt.Errorf("mono image must be %v, got %v", size, img.Bounds().Size())
+	}
+
+	var black uint8 = 0
+	if Luminance(img.Palette[1]) < Luminance(img.Palette[0]) {
+		black = 1
+	}
+
+	e.debug("Displaying mono image")
+	buffer := make([]byte, e.bufferSize)
+	min := img.Bounds().Min
+	for y := 0; y < e.bounds.Dy(); y++ {
+		for x := 0; x < e.bounds.Dx(); x++ {
+			lx, ly := e.toLogical(x, y)
+			if (img.Pix[img.PixOffset(min.X+lx, min.Y+ly)] == black) != e.inverted {
+				buffer[y*e.pixelWidth+x/PIXEL_SIZE] |= 0x80 >> (x % PIXEL_SIZE)
+			}
+		}
+	}
+
+	if err := e.withRecovery(func() error {
+		return e.display(buffer)
+	}); err != nil {
+		return err
+	}
+	e.debug("Mono image displayed")
+	return nil
+}
@@ -0,0 +1,345 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// An Option configures the driver when passed to New.
+type Option func(*Epd) error
+
+// Overrides the BCM pin used for Data/Command. Defaults to DEFAULT_DC_PIN (25).
+func WithDCPin(pin int) Option {
+	return func(e *Epd) error {
+		e.dc = pin
+		return nil
+	}
+}
+
+// Overrides the BCM pin used for Chip Select. Defaults to DEFAULT_CS_PIN (8).
+func WithCSPin(pin int) Option {
+	return func(e *Epd) error {
+		e.cs = pin
+		return nil
+	}
+}
+
+// Overrides the BCM pin used for Reset. Defaults to DEFAULT_RST_PIN (17).
+func WithResetPin(pin int) Option {
+	return func(e *Epd) error {
+		e.rst = pin
+		return nil
+	}
+}
+
+// Overrides the BCM pin used for Busy. Defaults to DEFAULT_BUSY_PIN (24).
+func WithBusyPin(pin int) Option {
+	return func(e *Epd) error {
+		e.busy = pin
+		return nil
+	}
+}
+
+// Sets the SPI clock speed in Hz. Defaults to DEFAULT_SPI_SPEED (4MHz), higher speeds
+// make full frame transfers faster but must not exceed MAX_SPI_SPEED (10MHz).
+func WithSPISpeed(hz int) Option {
+	return func(e *Epd) error {
+		if hz <= 0 || hz > MAX_SPI_SPEED {
+			return fmt.Errorf("SPI speed %dHz must be between 1 and %d", hz, MAX_SPI_SPEED)
+		}
+
+		e.spiSpeed = hz
+		return nil
+	}
+}
+
+// Selects the SPI bus the display is connected to. Defaults to 0, note that go-rpio
+// currently only supports SPI0.
+func WithSPIBus(bus int) Option {
+	return func(e *Epd) error {
+		if bus < 0 || bus > 2 {
+			return fmt.Errorf("SPI bus %d must be between 0 and 2", bus)
+		}
+
+		e.spiBus = bus
+		return nil
+	}
+}
+
+// Tells the default backend that the display data line is wired to MISO, enabling
+// ReadStatus and ReadTemperature. The waveshare HAT doesn't wire it and reads would
+// return garbage, so by default they fail with ErrReadNotSupported. Custom backends
+// enable reads by implementing Receiver instead.
+func WithMISO(wired bool) Option {
+	return func(e *Epd) error {
+		e.miso = wired
+		return nil
+	}
+}
+
+// Selects the SPI chip select line of the display. Defaults to 0 (CE0). To drive a second
+// panel on the same bus use 1 (CE1, BCM 7) together with WithCSPin(7) and its own
+// DC, RST and BUSY pins.
+func WithChipSelect(cs int) Option {
+	return func(e *Epd) error {
+		if cs < 0 || cs > 2 {
+			return fmt.Errorf("chip select %d must be between 0 and 2", cs)
+		}
+
+		e.spiChip = cs
+		return nil
+	}
+}
+
+// How the chip select line of the display is driven.
+type ChipSelectMode int
+
+const (
+	// The CS pin is configured as a GPIO output and pulled low around every transfer,
+	// like the waveshare examples do. This is the default and matches the HAT wiring.
+	ChipSelectManual ChipSelectMode = iota
+	// The CS pin is left to the SPI controller, which asserts the CE line selected with
+	// WithChipSelect during each transfer. WithCSPin is ignored.
+	ChipSelectHardware
+)
+
+// Selects how the chip select line is driven, see ChipSelectManual and ChipSelectHardware.
+func WithChipSelectMode(mode ChipSelectMode) Option {
+	return func(e *Epd) error {
+		if mode != ChipSelectManual && mode != ChipSelectHardware {
+			return fmt.Errorf("unknown chip select mode %d", mode)
+		}
+
+		e.csMode = mode
+		return nil
+	}
+}
+
+// Sets the maximum number of bytes sent in a single SPI transfer. Defaults to
+// MAX_CHUNK_SIZE (4096), only raise it if spidev.bufsiz was raised as well.
+func WithChunkSize(size int) Option {
+	return func(e *Epd) error {
+		if size <= 0 {
+			return fmt.Errorf("chunk size %d must be positive", size)
+		}
+
+		e.chunkSize = size
+		return nil
+	}
+}
+
+// Sets the luminance threshold used by DisplayImage and every method without an
+// explicit threshold, from 0 (everything white) to 255 (only white stays white).
+// Pixels below it are displayed as black. Defaults to DEFAULT_THRESHOLD (199).
+// Already encoded buffers are not affected.
+func WithThreshold(threshold uint8) Option {
+	return func(e *Epd) error {
+		e.threshold = threshold
+		return nil
+	}
+}
+
+// Retries failed SPI transfers up to n times, waiting a bit longer before each attempt,
+// see SPI_RETRY_BACKOFF. Defaults to 0, a single attempt.
+func WithSPIRetries(n int) Option {
+	return func(e *Epd) error {
+		if n < 0 {
+			return fmt.Errorf("SPI retries %d must not be negative", n)
+		}
+
+		e.spiRetries = n
+		return nil
+	}
+}
+
+// Calls fn after each chunk of data sent to the display with the number of bytes sent
+// so far and the total of the transfer, e.g. to show progress while a frame is sent.
+// fn runs while the driver is busy and must not call back into it.
+func WithProgress(fn func(sent, total int)) Option {
+	return func(e *Epd) error {
+		e.onProgress = fn
+		return nil
+	}
+}
+
+// Sets how long the reset line is pulled low and how long to wait around the pulse.
+// Defaults to RESET_PULSE (2ms) and RESET_SETTLE (20ms). Raise them if the display
+// doesn't reliably wake up, e.g. with long cables or level shifters. The pulse must
+// be at least 50µs.
+func WithResetTiming(pulse, settle time.Duration) Option {
+	return func(e *Epd) error {
+		if pulse < 50*time.Microsecond {
+			return fmt.Errorf("reset pulse %v must be at least 50µs", pulse)
+		}
+		if settle < 0 {
+			return fmt.Errorf("reset settle time %v must not be negative", settle)
+		}
+
+		e.resetPulse = pulse
+		e.resetSettle = settle
+		return nil
+	}
+}
+
+// Sets how long Sleep blocks after sending the display to deep sleep. Defaults to
+// SLEEP_DELAY (2s), 0 skips the wait entirely which speeds up shutdowns. The display
+// needs that time to settle though, cutting power or waking it up sooner may leave it
+// in an undefined state until the next reset.
+func WithSleepDelay(d time.Duration) Option {
+	return func(e *Epd) error {
+		if d < 0 {
+			return fmt.Errorf("sleep delay %v must not be negative", d)
+		}
+
+		e.sleepDelay = d
+		return nil
+	}
+}
+
+// Sets whether the busy line is low while the display is busy, which is the case for
+// the V2 and the default. Some clones use the opposite polarity: if every refresh
+// returns instantly or Init fails with ErrResetFailed although the wiring is right,
+// try WithBusyActiveLow(false). IsBusy reporting true while idle is a telltale sign.
+func WithBusyActiveLow(activeLow bool) Option {
+	return func(e *Epd) error {
+		e.busyActiveHigh = !activeLow
+		return nil
+	}
+}
+
+// Replaces time.Sleep for the delays of the driver, e.g. the waits around reset and
+// Sleep and between two polls of the busy line, so tests scripting the busy states
+// don't wait in real time. The busy timeout is still measured with the real clock, a
+// display that stays busy fails after WithBusyTimeout. Waiting for an edge with an
+// EdgeWaiter backend and the frame intervals of PlayFrames and Run aren't affected.
+func WithSleepFunc(fn func(time.Duration)) Option {
+	return func(e *Epd) error {
+		if fn == nil {
+			return errors.New("sleep function must not be nil")
+		}
+
+		e.sleepFunc = fn
+		return nil
+	}
+}
+
+// Sets how many more times Init and the other init methods reset the display and run
+// their sequence again when it doesn't power on, before returning ErrPowerOnTimeout.
+// Helps on supplies shared with other devices where the first POWER_ON sometimes
+// doesn't take. Defaults to DEFAULT_INIT_RETRIES (2), 0 disables the retries.
+func WithInitRetries(n int) Option {
+	return func(e *Epd) error {
+		if n < 0 {
+			return fmt.Errorf("init retries %d must not be negative", n)
+		}
+
+		e.initRetries = n
+		return nil
+	}
+}
+
+// Overrides the four BOOSTER_SOFT_START (0x06) bytes sent by Init, which set the soft
+// start phases of the booster: the strength and minimum off time of phase A, B and C,
+// and the duration of phase C2. Defaults to 0x17, 0x17, 0x28, 0x17, as in the waveshare
+// examples. Gentler values, e.g. a lower drive strength, can help panels that fail to
+// power on from weak supplies. InitFast and InitGray4 keep their own values.
+func WithBoosterSoftStart(values [4]byte) Option {
+	return func(e *Epd) error {
+		e.booster = values
+		return nil
+	}
+}
+
+// Sets how long to wait for the display to release the busy line before failing.
+// Defaults to BUSY_TIMEOUT (10s).
+func WithBusyTimeout(d time.Duration) Option {
+	return func(e *Epd) error {
+		if d <= 0 {
+			return fmt.Errorf("busy timeout %v must be positive", d)
+		}
+
+		e.busyTimeout = d
+		return nil
+	}
+}
+
+// Sets how often the busy line is checked while waiting for the display. A shorter
+// interval reduces the refresh latency at the cost of CPU. Defaults to BUSY_POLL_INTERVAL (100ms).
+func WithBusyPollInterval(d time.Duration) Option {
+	return func(e *Epd) error {
+		if d <= 0 {
+			return fmt.Errorf("busy poll interval %v must be positive", d)
+		}
+
+		e.pollInterval = d
+		return nil
+	}
+}
+
+// Re-reads the busy line up to max times, delay apart, after each poll interval in
+// which the display was still busy. Reading it in short bursts catches the line being
+// released briefly between coarse polls, e.g. when refreshes seem to never finish.
+// Passing 0 for max disables it, which is the default.
+func WithBusyReread(delay time.Duration, max int) Option {
+	return func(e *Epd) error {
+		if delay <= 0 {
+			return fmt.Errorf("busy reread delay %v must be positive", delay)
+		}
+		if max < 0 {
+			return fmt.Errorf("busy rereads %d must not be negative", max)
+		}
+
+		e.rereadDelay = delay
+		e.rereadMax = max
+		return nil
+	}
+}
+
+// Waits for the busy line to be released using the edge detection of the Backend when
+// it implements EdgeWaiter, instead of checking it every poll interval. The driver
+// then notices the end of a refresh right away and doesn't wake up to poll, which
+// suits battery powered setups. Backends without EdgeWaiter, including the default
+// go-rpio one, keep polling. Defaults to false.
+func WithEdgeWait(enabled bool) Option {
+	return func(e *Epd) error {
+		e.edgeWait = enabled
+		return nil
+	}
+}
+
+// Ensures no two control lines were assigned the same pin.
+func validatePins(e *Epd) error {
+	type assignment struct {
+		name string
+		pin  int
+	}
+
+	pins := []assignment{
+		{"DC", e.dc},
+		{"RST", e.rst},
+		{"BUSY", e.busy},
+	}
+	// With hardware chip select the CS pin is left to the SPI controller
+	if e.csMode == ChipSelectManual {
+		pins = append(pins, assignment{"CS", e.cs})
+	}
+	if e.demux != nil {
+		if e.csMode != ChipSelectManual {
+			return errors.New("a demux requires ChipSelectManual")
+		}
+		for i, pin := range e.demux.pins {
+			pins = append(pins, assignment{fmt.Sprintf("demux address %d", i), pin})
+		}
+	}
+
+	for i := range pins {
+		for j := i + 1; j < len(pins); j++ {
+			if pins[i].pin == pins[j].pin {
+				return fmt.Errorf("pin %d is assigned to both %s and %s", pins[i].pin, pins[i].name, pins[j].name)
+			}
+		}
+	}
+
+	return nil
+}
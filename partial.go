@@ -0,0 +1,464 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"time"
+)
+
+// Powers on the screen using the register setup for partial refresh. Must be used
+// instead of Init before calling DisplayPartial. Run Init again to go back to full refresh.
+func (e *Epd) InitPartial() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.initPartial()
+}
+
+func (e *Epd) initPartial() error {
+	return e.retryInit(e.initPartialOnce)
+}
+
+func (e *Epd) initPartialOnce() error {
+	e.debug("Initializing display for partial refresh")
+	if err := e.reset(); err != nil {
+		return err
+	}
+
+	if err := e.sendCommandWithData(PANEL_SETTING, []byte{
+		0x1F, // KW-3f  KWR-2F	BWROTP 0f	BWOTP 1f
+	}); err != nil {
+		return err
+	}
+
+	if err := e.sendCommand(POWER_ON); err != nil {
+		return err
+	}
+	e.wait(100 * time.Millisecond)
+	if err := e.waitUntilIdle(); err != nil {
+		return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+	}
+
+	if err := e.sendCommandWithData(CASCADE_SETTING, []byte{0x02}); err != nil {
+		return err
+	}
+	if err := e.sendCommandWithData(FORCE_TEMPERATURE, []byte{0x6E}); err != nil {
+		return err
+	}
+
+	if err := e.sendVCOMDataInterval(modePartial); err != nil {
+		return err
+	}
+
+	e.asleep = false
+	e.poweredOff = false
+	e.mode = modePartial
+	e.debug("Display initialized for partial refresh")
+	return nil
+}
+
+// Writes img to both the old (0x10) and new (0x13) data buffers and does a full refresh,
+// establishing the reference frame partial refreshes are compared against. Without it
+// partial refreshes ghost badly. Call it once after InitPartial, then DisplayPartial repeatedly.
+func (e *Epd) SetBaseImage(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		return e.setBaseImage(img)
+	})
+}
+
+func (e *Epd) setBaseImage(img image.Image) error {
+	e.debug("Setting base image")
+	buffer := e.getBuffer(img, e.threshold)
+	defer e.releaseBuffer(buffer)
+	if err := e.display(buffer); err != nil {
+		return err
+	}
+	e.debug("Base image set")
+	return nil
+}
+
+// Updates only the region r of the screen with the matching pixels of img, without
+// flashing the rest of the panel. r is clamped to Bounds() and may have any size: the
+// panel is written in whole bytes of 8 pixels, so the pixels around r needed to fill
+// them are sent again as they were in the last frame. Only the new data buffer is
+// written, so InitPartial followed by SetBaseImage must be called first. The planes of
+// the panel are unknown after a reset, e.g. by InitPartial or a grayscale frame, and
+// a partial refresh from them garbles the screen: until a full frame was displayed the
+// refresh is promoted to a full one, see DisplayPartialPromoted.
+func (e *Epd) DisplayPartial(img image.Image, r image.Rectangle) error {
+	_, err := e.DisplayPartialPromoted(img, r)
+	return err
+}
+
+// Same as DisplayPartial but reports whether the refresh was promoted to a full one,
+// because the planes of the panel were unknown or WithFullRefreshInterval was reached.
+func (e *Epd) DisplayPartialPromoted(img image.Image, r image.Rectangle) (bool, error) {
+	if err := checkImage(img); err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var promoted bool
+	err := e.withRecovery(func() error {
+		var err error
+		promoted, err = e.displayPartialPromoted(img, r)
+		return err
+	})
+	return promoted, err
+}
+
+func (e *Epd) displayPartial(img image.Image, r image.Rectangle) error {
+	_, err := e.displayPartialPromoted(img, r)
+	return err
+}
+
+func (e *Epd) displayPartialPromoted(img image.Image, r image.Rectangle) (bool, error) {
+	e.debug("Displaying partial image")
+
+	native := e.rectToNative(r.Intersect(e.logicalBounds()))
+	r = e.alignRegion(native)
+	if r.Empty() {
+		return false, errors.New("region is outside of the display bounds")
+	}
+
+	buffer := e.getRegionBuffer(img, r, e.threshold)
+	// Without a known frame the padding pixels are taken from img, like the region
+	if e.lastBuffer != nil {
+		e.keepOutside(buffer, r, native)
+	}
+	promoted, err := e.sendPartialPromoted(context.Background(), buffer, r)
+	if err != nil {
+		return promoted, err
+	}
+
+	e.debug("Partial image displayed")
+	return promoted, nil
+}
+
+// Reports whether the next n partial refreshes must be promoted to a full one, because
+// the planes of the panel don't hold the last frame or the full refresh interval is
+// reached.
+func (e *Epd) promotionDue(n int) bool {
+	if !e.frameValid {
+		e.debug("Display planes unknown since the last reset")
+		return true
+	}
+
+	return e.fullInterval > 0 && e.partialCount+n >= e.fullInterval
+}
+
+// Same as DisplayPartial but writes the region of prev into the old plane before the
+// region of next into the new one, so the waveform transitions from what the caller
+// knows to be on screen rather than from the frame the driver remembers. Use it when
+// that frame may be stale, e.g. after another process drew on the display. prev and
+// next are read at screen coordinates like with DisplayPartial, including the pixels
+// around r that are sent to fill whole bytes. Like DisplayPartial the refresh is
+// promoted to a full one showing next when the planes of the panel are unknown or
+// WithFullRefreshInterval is reached. Requires InitPartial to be called first.
+func (e *Epd) DisplayPartialWithPrevious(prev, next image.Image, r image.Rectangle) error {
+	for _, img := range []image.Image{prev, next} {
+		if err := checkImage(img); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r = e.alignRegion(e.rectToNative(r.Intersect(e.logicalBounds())))
+	if r.Empty() {
+		return errors.New("region is outside of the display bounds")
+	}
+
+	e.debug("Displaying partial image with previous content")
+	old := e.getRegionBuffer(prev, r, e.threshold)
+	buffer := e.getRegionBuffer(next, r, e.threshold)
+
+	return e.withRecovery(func() error {
+		if e.promotionDue(1) {
+			return e.promotePartial(buffer, r)
+		}
+		return e.sendPartialPlanes(context.Background(), old, buffer, r)
+	})
+}
+
+// Turns every nth DisplayPartial into a full refresh, clearing the ghosting left by
+// repeated partial refreshes. Defaults to 0, never.
+func WithFullRefreshInterval(n int) Option {
+	return func(e *Epd) error {
+		if n < 0 {
+			return fmt.Errorf("full refresh interval %d must not be negative", n)
+		}
+
+		e.fullInterval = n
+		return nil
+	}
+}
+
+// Returns the number of partial refreshes done since the last full refresh.
+func (e *Epd) PartialCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.partialCount
+}
+
+// Shows the region buffer, in native coordinates, on top of the last frame with a
+// full refresh and goes back to partial refresh mode, reloading the planes the reset
+// cleared.
+func (e *Epd) promotePartial(buffer []byte, r image.Rectangle) error {
+	e.debug("Promoting partial refresh to a full refresh")
+	if e.lastBuffer == nil {
+		e.storeBuffer(e.whiteBuffer())
+	}
+	e.storeRegion(buffer, r)
+	frame := append([]byte(nil), e.lastBuffer...)
+
+	if err := e.initFull(); err != nil {
+		return err
+	}
+	if err := e.display(frame); err != nil {
+		return err
+	}
+
+	if err := e.initPartial(); err != nil {
+		return err
+	}
+	return e.restorePlanes()
+}
+
+// Returns a full frame that shows as white.
+func (e *Epd) whiteBuffer() []byte {
+	var fill byte = 0x00
+	if e.inverted {
+		fill = 0xFF
+	}
+
+	return bytes.Repeat([]byte{fill}, e.bufferSize)
+}
+
+// Blanks the screen to white using the partial refresh waveform, avoiding the black
+// and white flashing of Clear. Only the pixels that weren't white are driven so some
+// ghosting may remain, use it for subtle updates like clocks and Clear every now and
+// then to fully clean the panel. The display is left in partial refresh mode, ready
+// for DisplayPartial. Run Init to go back to full refresh.
+func (e *Epd) QuickClear() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.debug("Quick clearing display")
+	if err := e.withRecovery(func() error {
+		if e.mode != modePartial {
+			if err := e.initPartial(); err != nil {
+				return err
+			}
+			// The old plane must hold what is on screen for the partial waveform
+			if err := e.restorePlanes(); err != nil {
+				return err
+			}
+		}
+
+		return e.sendPartial(context.Background(), e.whiteBuffer(), e.bounds)
+	}); err != nil {
+		return err
+	}
+
+	e.debug("Display quick cleared")
+	return nil
+}
+
+// Blanks the region r of the screen to white with a partial refresh, leaving the rest
+// untouched, e.g. to erase a stale widget. r is clamped like with DisplayPartial, which
+// requires InitPartial to be called first as well.
+func (e *Epd) ClearRegion(r image.Rectangle) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	native := e.rectToNative(r.Intersect(e.logicalBounds()))
+	r = e.alignRegion(native)
+	if r.Empty() {
+		return errors.New("region is outside of the display bounds")
+	}
+
+	e.debug("Clearing region")
+	buffer := e.whiteRegion(r)
+	e.keepOutside(buffer, r, native)
+	if err := e.withRecovery(func() error {
+		return e.sendPartial(context.Background(), buffer, r)
+	}); err != nil {
+		return err
+	}
+
+	e.debug("Region cleared")
+	return nil
+}
+
+// Returns a buffer of the byte aligned region r that shows as white.
+func (e *Epd) whiteRegion(r image.Rectangle) []byte {
+	var fill byte = 0x00
+	if e.inverted {
+		fill = 0xFF
+	}
+
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	return bytes.Repeat([]byte{fill}, rowSize*r.Dy())
+}
+
+// Inverts the pixels of the region r of what is currently on screen with a partial
+// refresh, e.g. to highlight a selected item without rendering the frame again. Calling
+// it twice restores the region. Requires InitPartial and a frame displayed before.
+func (e *Epd) InvertRegion(r image.Rectangle) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	aligned, _, inverted, err := e.invertedRegion(r)
+	if err != nil {
+		return err
+	}
+
+	e.debug("Inverting region")
+	if err := e.withRecovery(func() error {
+		return e.sendPartial(context.Background(), inverted, aligned)
+	}); err != nil {
+		return err
+	}
+
+	e.debug("Region inverted")
+	return nil
+}
+
+// Returns the byte aligned region holding r in native coordinates, with the pixels of
+// the frame on screen in it as they are and with those of r inverted.
+func (e *Epd) invertedRegion(r image.Rectangle) (image.Rectangle, []byte, []byte, error) {
+	if e.lastBuffer == nil {
+		return image.Rectangle{}, nil, nil, errors.New("no frame displayed yet")
+	}
+
+	native := e.rectToNative(r.Intersect(e.logicalBounds()))
+	aligned := e.alignRegion(native)
+	if aligned.Empty() {
+		return image.Rectangle{}, nil, nil, errors.New("region is outside of the display bounds")
+	}
+
+	original := e.cropBuffer(e.lastBuffer, aligned)
+	inverted := append([]byte(nil), original...)
+	rowSize := (aligned.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	for y := native.Min.Y; y < native.Max.Y; y++ {
+		for x := native.Min.X; x < native.Max.X; x++ {
+			inverted[(y-aligned.Min.Y)*rowSize+(x-aligned.Min.X)/PIXEL_SIZE] ^= 0x80 >> (x % PIXEL_SIZE)
+		}
+	}
+
+	return aligned, original, inverted, nil
+}
+
+// Writes buffer into the byte aligned region r, in native coordinates, and refreshes
+// only that region. Wakes the display first if it sleeps. The refresh is promoted to a
+// full one when it's due, see promotionDue, so buffer must hold the pixels of the last
+// frame around the region.
+func (e *Epd) sendPartial(ctx context.Context, buffer []byte, r image.Rectangle) error {
+	_, err := e.sendPartialPromoted(ctx, buffer, r)
+	return err
+}
+
+// Same as sendPartial but reports whether the refresh was promoted to a full one.
+func (e *Epd) sendPartialPromoted(ctx context.Context, buffer []byte, r image.Rectangle) (bool, error) {
+	if e.promotionDue(1) {
+		return true, e.promotePartial(buffer, r)
+	}
+
+	return false, e.sendPartialPlanes(ctx, nil, buffer, r)
+}
+
+// Same as sendPartial but also writes old into the old plane first, unless it is nil.
+func (e *Epd) sendPartialPlanes(ctx context.Context, old, buffer []byte, r image.Rectangle) (err error) {
+	if err := e.wake(); err != nil {
+		return err
+	}
+
+	if err := e.sendCommand(PARTIAL_IN); err != nil {
+		return err
+	}
+	defer func() {
+		if outErr := e.sendCommand(PARTIAL_OUT); err == nil {
+			err = outErr
+		}
+	}()
+
+	if err := e.sendCommandWithData(PARTIAL_WINDOW, []byte{
+		byte(r.Min.X >> 8), // x-start
+		byte(r.Min.X),
+		byte((r.Max.X - 1) >> 8), // x-end
+		byte(r.Max.X - 1),
+		byte(r.Min.Y >> 8), // y-start
+		byte(r.Min.Y),
+		byte((r.Max.Y - 1) >> 8), // y-end
+		byte(r.Max.Y - 1),
+		0x01, // gates scan both inside and outside of the partial window
+	}); err != nil {
+		return err
+	}
+
+	if old != nil {
+		if err := e.sendCommandWithData(DISPLAY_START_TRANSMISSION_1, old); err != nil {
+			return err
+		}
+	}
+	if err := e.sendCommandWithData(DISPLAY_START_TRANSMISSION_2, buffer); err != nil {
+		return err
+	}
+	e.storeRegion(buffer, r)
+	e.partialCount++
+
+	return e.turnOnDisplayContext(ctx)
+}
+
+// Clamps r, in native panel coordinates, to the panel bounds and widens it on the
+// x axis to whole bytes.
+func (e *Epd) alignRegion(r image.Rectangle) image.Rectangle {
+	r = r.Intersect(e.bounds)
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+
+	r.Min.X -= r.Min.X % PIXEL_SIZE
+	if rem := r.Max.X % PIXEL_SIZE; rem != 0 {
+		r.Max.X += PIXEL_SIZE - rem
+	}
+
+	return r.Intersect(e.bounds)
+}
+
+// Restores the pixels of the region buffer r that fall outside of keep, both in native
+// coordinates, to the last frame sent. White is used if the frame is unknown.
+func (e *Epd) keepOutside(buffer []byte, r, keep image.Rectangle) {
+	previous := e.lastBuffer
+	if previous == nil {
+		previous = e.whiteBuffer()
+	}
+
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if (image.Point{x, y}).In(keep) {
+				continue
+			}
+
+			mask := byte(0x80 >> (x % PIXEL_SIZE))
+			index := (y-r.Min.Y)*rowSize + (x-r.Min.X)/PIXEL_SIZE
+			buffer[index] = buffer[index]&^mask | previous[y*e.pixelWidth+x/PIXEL_SIZE]&mask
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"testing"
+)
+
+// Draws a black frame with a full refresh and resets the display into partial mode,
+// leaving its planes unknown.
+func newResetEpd(t *testing.T) (*Epd, *FakeBackend) {
+	t.Helper()
+
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	black := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	if err := e.DisplayImage(black); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.InitPartial(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ClearTransfers()
+	return e, fake
+}
+
+func TestPartialAfterResetPromotes(t *testing.T) {
+	e, fake := newResetEpd(t)
+
+	white := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+	r := image.Rect(0, 0, 16, 16)
+
+	promoted, err := e.DisplayPartialPromoted(white, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !promoted {
+		t.Error("first partial refresh after a reset wasn't promoted")
+	}
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) >= 0 {
+		t.Error("promoted refresh used a partial window")
+	}
+	if got := fake.Screen().GrayAt(0, 0); got.Y != 255 {
+		t.Errorf("region shows %v, want white", got)
+	}
+	if got := fake.Screen().GrayAt(100, 100); got.Y != 0 {
+		t.Errorf("rest of the frame shows %v, want black", got)
+	}
+
+	fake.ClearTransfers()
+	promoted, err = e.DisplayPartialPromoted(white, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if promoted {
+		t.Error("partial refresh with known planes was promoted")
+	}
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) < 0 {
+		t.Error("partial refresh didn't use a partial window")
+	}
+}
+
+func TestPartialHelpersAfterResetPromote(t *testing.T) {
+	r := image.Rect(0, 0, 16, 16)
+	tile := image.NewGray(r)
+	draw.Draw(tile, r, image.White, image.Point{}, draw.Src)
+
+	for name, update := range map[string]func(e *Epd) error{
+		"ClearRegion":  func(e *Epd) error { return e.ClearRegion(r) },
+		"InvertRegion": func(e *Epd) error { return e.InvertRegion(r) },
+		"DisplayTile":  func(e *Epd) error { return e.DisplayTile(tile, r) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			e, fake := newResetEpd(t)
+			if err := update(e); err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.IndexByte(fake.Commands(), PARTIAL_IN) >= 0 {
+				t.Error("first partial refresh after a reset used a partial window")
+			}
+			if got := fake.Screen().GrayAt(0, 0); got.Y != 255 {
+				t.Errorf("region shows %v, want white", got)
+			}
+			if got := fake.Screen().GrayAt(100, 100); got.Y != 0 {
+				t.Errorf("rest of the frame shows %v, want black", got)
+			}
+		})
+	}
+}
+
+func TestDisplayPartialWithPreviousReadsScreenCoordinates(t *testing.T) {
+	e, fake := newDiffEpd(t)
+
+	r := image.Rect(100, 100, 116, 116)
+	prev := image.NewGray(r)
+	draw.Draw(prev, r, image.White, image.Point{}, draw.Src)
+	next := image.NewGray(r)
+	if err := e.DisplayPartialWithPrevious(prev, next, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) < 0 {
+		t.Error("refresh with known planes didn't use a partial window")
+	}
+	if got := fake.Screen().GrayAt(108, 108); got.Y != 0 {
+		t.Errorf("region shows %v, want black", got)
+	}
+}
+
+func TestDisplayPartialWithPreviousAfterResetPromotes(t *testing.T) {
+	e, fake := newResetEpd(t)
+
+	r := image.Rect(0, 0, 16, 16)
+	next := image.NewGray(r)
+	draw.Draw(next, r, image.White, image.Point{}, draw.Src)
+	if err := e.DisplayPartialWithPrevious(image.NewGray(r), next, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) >= 0 {
+		t.Error("first partial refresh after a reset used a partial window")
+	}
+	if got := fake.Screen().GrayAt(0, 0); got.Y != 255 {
+		t.Errorf("region shows %v, want white", got)
+	}
+}
@@ -0,0 +1,102 @@
+package waveshare7in5v2
+
+import (
+	"context"
+	"fmt"
+	"image"
+)
+
+// Sets the pixel at x, y to black or white, without touching the screen until Commit
+// is called. Meant for tiny updates such as toggling a status dot, use a Canvas to draw
+// anything larger. Pixels that are not set keep showing the last frame.
+func (e *Epd) SetPixel(x, y int, black bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !(image.Point{x, y}.In(e.logicalBounds())) {
+		return fmt.Errorf("pixel (%d, %d) is outside of the display bounds", x, y)
+	}
+
+	if e.pendingPixels == nil {
+		e.pendingPixels = make(map[image.Point]bool)
+	}
+
+	nx, ny := e.toNative(x, y)
+	e.pendingPixels[image.Pt(nx, ny)] = black
+	return nil
+}
+
+// Reports whether the pixel at x, y is black, including the changes made with SetPixel
+// that were not committed yet. Pixels of a screen that was never updated read as white.
+// Like At, black is the color of the images displayed: WithInverted doesn't swap it.
+func (e *Epd) GetPixel(x, y int) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !(image.Point{x, y}.In(e.logicalBounds())) {
+		return false, fmt.Errorf("pixel (%d, %d) is outside of the display bounds", x, y)
+	}
+
+	nx, ny := e.toNative(x, y)
+	if black, ok := e.pendingPixels[image.Pt(nx, ny)]; ok {
+		return black, nil
+	}
+	return e.frameBlack(nx, ny), nil
+}
+
+// Reports whether the pixel at x, y in native coordinates was black in the last frame
+// before inversion, false if the frame is unknown.
+func (e *Epd) frameBlack(x, y int) bool {
+	if e.lastBuffer == nil {
+		return false
+	}
+
+	set := e.lastBuffer[y*e.pixelWidth+x/PIXEL_SIZE]&(0x80>>(x%PIXEL_SIZE)) != 0
+	return set != e.inverted
+}
+
+// Shows the pixels changed with SetPixel on top of the last frame. After InitPartial
+// only the area around them is updated with a partial refresh, otherwise the whole
+// screen is refreshed. Does nothing if no pixel was changed.
+func (e *Epd) Commit() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.pendingPixels) == 0 {
+		return nil
+	}
+
+	frame := e.whiteBuffer()
+	if e.lastBuffer != nil {
+		copy(frame, e.lastBuffer)
+	}
+
+	var dirty image.Rectangle
+	for pt, black := range e.pendingPixels {
+		index, mask := pt.Y*e.pixelWidth+pt.X/PIXEL_SIZE, byte(0x80>>(pt.X%PIXEL_SIZE))
+		if black != e.inverted {
+			frame[index] |= mask
+		} else {
+			frame[index] &^= mask
+		}
+
+		dirty = dirty.Union(image.Rect(pt.X, pt.Y, pt.X+1, pt.Y+1))
+	}
+
+	err := e.withRecovery(func() error {
+		if e.mode != modePartial {
+			return e.display(frame)
+		}
+
+		r := e.alignRegion(dirty)
+		return e.sendPartial(context.Background(), e.cropBuffer(frame, r), r)
+	})
+	if err != nil {
+		return err
+	}
+
+	// A partial refresh only records its region when the frame was known before
+	e.storeBuffer(frame)
+	e.pendingPixels = nil
+	return nil
+}
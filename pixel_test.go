@@ -0,0 +1,34 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"testing"
+)
+
+func TestGetPixelMatchesAt(t *testing.T) {
+	for _, inverted := range []bool{false, true} {
+		e, _ := newTestEpd(t, WithInverted(inverted))
+		if err := e.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.SetPixel(3, 4, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, pt := range []image.Point{{3, 4}, {4, 4}} {
+			black, err := e.GetPixel(pt.X, pt.Y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if at := e.At(pt.X, pt.Y) == monoBlack; at != black {
+				t.Errorf("inverted %v: pixel %v is black %v for At, %v for GetPixel", inverted, pt, at, black)
+			}
+			if want := pt.X == 3; black != want {
+				t.Errorf("inverted %v: pixel %v is black %v, want %v", inverted, pt, black, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package waveshare7in5v2
+
+import "fmt"
+
+// The V2 controller keeps two frame planes in its RAM:
+//
+//   - DISPLAY_START_TRANSMISSION_1 (0x10) loads the "old" plane, the image currently on screen
+//   - DISPLAY_START_TRANSMISSION_2 (0x13) loads the "new" plane, the image to show next
+//
+// The waveform drives each pixel based on its old and new value. DisplayImage writes the
+// same frame to both, writing them independently allows for ghost free partial updates.
+// Buffers are sent as is, 1 bit per pixel with 1 being black unless WithBufferPolarity
+// says otherwise, ignoring rotation and inversion.
+
+// The meaning of a set bit in the raw buffers passed to DisplayBuffer and friends.
+type BufferPolarity int
+
+const (
+	// A set bit is a black pixel, the convention of the V2 and of EncodeImage.
+	BlackIsSet BufferPolarity = iota
+	// A set bit is a white pixel, the convention of some other waveshare panels.
+	WhiteIsSet
+)
+
+// Sets the bit convention of the raw buffers passed to DisplayBuffer, DisplayBuffers,
+// DisplayNewPlaneOnly, WriteOldBuffer and WriteNewBuffer, so rendering code written for
+// panels using the opposite convention can be reused as is. Defaults to BlackIsSet, the
+// convention of the V2. Unlike WithInverted this doesn't change what is shown: a white
+// pixel stays white whatever the polarity, it only changes how the buffers are read.
+func WithBufferPolarity(polarity BufferPolarity) Option {
+	return func(e *Epd) error {
+		if polarity != BlackIsSet && polarity != WhiteIsSet {
+			return fmt.Errorf("unknown buffer polarity %d", polarity)
+		}
+
+		e.polarity = polarity
+		return nil
+	}
+}
+
+// Writes buffer to the old plane without refreshing the display.
+func (e *Epd) WriteOldBuffer(buffer []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.writePlane(DISPLAY_START_TRANSMISSION_1, e.fromPolarity(buffer)); err != nil {
+		return err
+	}
+
+	// The old plane no longer holds the last frame
+	e.planesSynced = false
+	return nil
+}
+
+// Writes buffer to the new plane without refreshing the display, run Refresh to show it.
+func (e *Epd) WriteNewBuffer(buffer []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buffer = e.fromPolarity(buffer)
+
+	if err := e.writePlane(DISPLAY_START_TRANSMISSION_2, buffer); err != nil {
+		return err
+	}
+
+	// The planes differ until Refresh
+	e.planesSynced = false
+	e.storeBuffer(buffer)
+	return nil
+}
+
+// Refreshes the display with the content of its planes.
+func (e *Epd) Refresh() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.withRecovery(func() error {
+		if err := e.wake(); err != nil {
+			return err
+		}
+
+		return e.turnOnDisplay()
+	})
+}
+
+// Displays a pre-encoded buffer, e.g. from EncodeImage, writing it to both planes.
+// Returns ErrBufferSize if buffer isn't exactly Config().BufferSize bytes long.
+func (e *Epd) DisplayBuffer(buffer []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buffer = e.fromPolarity(buffer)
+
+	if err := e.checkBufferSize(buffer); err != nil {
+		return err
+	}
+
+	return e.withRecovery(func() error {
+		return e.display(buffer)
+	})
+}
+
+// Writes old to the old plane and new to the new plane, then refreshes the display.
+// Gives full control over what the waveform transitions from and to, e.g. old holding
+// the frame on screen for a ghost free update. Both must be Config().BufferSize long.
+func (e *Epd) DisplayBuffers(old, new []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	old, new = e.fromPolarity(old), e.fromPolarity(new)
+
+	if err := e.checkBufferSize(old); err != nil {
+		return err
+	}
+	if err := e.checkBufferSize(new); err != nil {
+		return err
+	}
+
+	return e.withRecovery(func() error {
+		if err := e.writePlane(DISPLAY_START_TRANSMISSION_1, old); err != nil {
+			return err
+		}
+		if err := e.writePlane(DISPLAY_START_TRANSMISSION_2, new); err != nil {
+			return err
+		}
+		e.planesWritten(new)
+
+		return e.turnOnDisplay()
+	})
+}
+
+// Same as DisplayBuffer but only writes the new plane, halving the data sent over SPI.
+// The old plane must already hold the frame on screen, so a full DisplayImage or
+// DisplayBuffer must precede it. Meant for consecutive black and white frames.
+func (e *Epd) DisplayNewPlaneOnly(buffer []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buffer = e.fromPolarity(buffer)
+
+	return e.withRecovery(func() error {
+		if err := e.writePlane(DISPLAY_START_TRANSMISSION_2, buffer); err != nil {
+			return err
+		}
+		e.planesWritten(buffer)
+
+		return e.turnOnDisplay()
+	})
+}
+
+// Records that the planes hold the full frame buffer about to be refreshed, like
+// display does.
+func (e *Epd) planesWritten(buffer []byte) {
+	e.planesSynced = true
+	e.frameValid = true
+	e.storeBuffer(buffer)
+	e.partialCount = 0
+}
+
+func (e *Epd) writePlane(cmd byte, buffer []byte) error {
+	if err := e.checkBufferSize(buffer); err != nil {
+		return err
+	}
+
+	if err := e.wake(); err != nil {
+		return err
+	}
+
+	return e.sendCommandWithData(cmd, buffer)
+}
+
+func (e *Epd) checkBufferSize(buffer []byte) error {
+	if len(buffer) != e.bufferSize {
+		return fmt.Errorf("%w: got %d bytes, want %d", ErrBufferSize, len(buffer), e.bufferSize)
+	}
+
+	return nil
+}
+
+// Converts a raw buffer from the configured polarity to the one of the V2.
+func (e *Epd) fromPolarity(buffer []byte) []byte {
+	if e.polarity == BlackIsSet {
+		return buffer
+	}
+
+	converted := make([]byte, len(buffer))
+	for i, b := range buffer {
+		converted[i] = ^b
+	}
+
+	return converted
+}
+
+// Only writes the new data plane (0x13) in DisplayImage and friends once a first full
+// frame wrote both, cutting the SPI traffic of every refresh in half. Meant for apps
+// showing similar content with full refreshes only: the old plane then holds an older
+// frame rather than the previous one, so the waveform works from the wrong content
+// and ghosting can build up, more so after partial refreshes or raw plane writes.
+// Use ForceFullPlanes to write both planes again. Defaults to false.
+func WithSinglePlaneWrites(enabled bool) Option {
+	return func(e *Epd) error {
+		e.singlePlane = enabled
+		return nil
+	}
+}
+
+// Makes the next frame write both data planes, re-syncing the old plane after
+// ghosting appeared with WithSinglePlaneWrites. Init and Wake already do.
+func (e *Epd) ForceFullPlanes() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.planesSynced = false
+}
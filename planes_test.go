@@ -0,0 +1,72 @@
+package waveshare7in5v2
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestDisplayBuffersValidatesPlanes(t *testing.T) {
+	e, fake := newTestEpd(t, WithFullRefreshInterval(3))
+	if err := e.InitPartial(); err != nil {
+		t.Fatal(err)
+	}
+
+	white := make([]byte, ROW_SIZE*EPD_HEIGHT)
+	if err := e.DisplayBuffers(white, white); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.PartialCount(); got != 0 {
+		t.Errorf("partial count is %d after a full refresh, want 0", got)
+	}
+
+	fake.ClearTransfers()
+	if err := e.ClearRegion(image.Rect(0, 0, 8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) < 0 {
+		t.Error("partial refresh after DisplayBuffers was promoted")
+	}
+}
+
+func TestDisplayNewPlaneOnlyValidatesPlanes(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.InitPartial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.DisplayNewPlaneOnly(make([]byte, ROW_SIZE*EPD_HEIGHT)); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ClearTransfers()
+	if err := e.ClearRegion(image.Rect(0, 0, 8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.IndexByte(fake.Commands(), PARTIAL_IN) < 0 {
+		t.Error("partial refresh after DisplayNewPlaneOnly was promoted")
+	}
+}
+
+func TestWriteNewBufferUnsyncsPlanes(t *testing.T) {
+	e, fake := newTestEpd(t, WithSinglePlaneWrites(true))
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, ROW_SIZE*EPD_HEIGHT)
+	if err := e.DisplayBuffer(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.WriteNewBuffer(bytes.Repeat([]byte{0xFF}, len(frame))); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ClearTransfers()
+	if err := e.DisplayBuffer(frame); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.IndexByte(fake.Commands(), DISPLAY_START_TRANSMISSION_1) < 0 {
+		t.Error("old plane wasn't written again after WriteNewBuffer")
+	}
+}
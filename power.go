@@ -0,0 +1,52 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"time"
+)
+
+// Turns off the panel power supplies while keeping the registers and the image memory,
+// saving energy between updates. Unlike Sleep no init is needed afterwards, PowerOn or
+// the next update powers it back on cheaply.
+func (e *Epd) PowerOff() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.asleep || e.poweredOff {
+		return nil
+	}
+
+	e.debug("Powering off display")
+	if err := e.sendCommand(POWER_OFF); err != nil {
+		return err
+	}
+	if err := e.waitUntilIdle(); err != nil {
+		return err
+	}
+
+	e.poweredOff = true
+	return nil
+}
+
+// Turns the panel power supplies back on after PowerOff.
+// After Sleep use Wake instead, deep sleep loses the registers and needs a full init.
+func (e *Epd) PowerOn() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.wake()
+}
+
+func (e *Epd) powerOn() error {
+	e.debug("Powering on display")
+	if err := e.sendCommand(POWER_ON); err != nil {
+		return err
+	}
+	e.wait(100 * time.Millisecond)
+	if err := e.waitUntilIdle(); err != nil {
+		return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+	}
+
+	e.poweredOff = false
+	return nil
+}
@@ -0,0 +1,126 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Returns the last frame sent to the display as a black and white image, as seen on
+// the screen. Returns nil if nothing was displayed yet or the frame is unknown, e.g.
+// after a grayscale refresh.
+func (e *Epd) BufferImage() *image.Gray {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.bufferImage()
+}
+
+// Writes the last frame sent to the display as a PNG, allowing to preview layouts
+// without looking at the panel. Writes a white frame if nothing was displayed yet.
+func (e *Epd) SaveBuffer(w io.Writer) error {
+	e.mu.Lock()
+	img := e.bufferImage()
+	if img == nil {
+		img = e.decodeBuffer(make([]byte, e.bufferSize))
+	}
+	e.mu.Unlock()
+
+	return png.Encode(w, img)
+}
+
+// Epd implements image.Image reflecting the last frame sent to the display, in the
+// coordinates of the rotated screen. Unknown frames read as white. The colors are those
+// of the images displayed, like GetPixel, so with WithInverted they are the opposite of
+// what the screen and BufferImage show.
+func (e *Epd) At(x, y int) color.Color {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !(image.Point{x, y}.In(e.logicalBounds())) {
+		return monoWhite
+	}
+
+	if e.frameBlack(e.toNative(x, y)) {
+		return monoBlack
+	}
+
+	return monoWhite
+}
+
+func (e *Epd) ColorModel() color.Model {
+	return MonoModel
+}
+
+// Returns how img would look on the display for each of the candidate thresholds,
+// without needing a device, so the best one can be picked from a contact sheet. Once
+// displayed, SaveBuffer shows what was actually sent. The images have the size of the
+// panel in its native orientation.
+func PreviewThresholds(img image.Image, thresholds []uint8) []image.Image {
+	e := &Epd{bounds: image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT), pixelWidth: ROW_SIZE}
+
+	previews := make([]image.Image, len(thresholds))
+	for i, threshold := range thresholds {
+		previews[i] = e.decodeBuffer(EncodeImage(img, threshold))
+	}
+
+	return previews
+}
+
+func (e *Epd) bufferImage() *image.Gray {
+	if e.lastBuffer == nil {
+		return nil
+	}
+
+	return e.decodeBuffer(e.lastBuffer)
+}
+
+// Converts a buffer in the display format back into an image, in the coordinates of
+// the rotated screen.
+func (e *Epd) decodeBuffer(buffer []byte) *image.Gray {
+	img := image.NewGray(e.logicalBounds())
+
+	for y := 0; y < e.bounds.Dy(); y++ {
+		for x := 0; x < e.bounds.Dx(); x++ {
+			var value uint8 = 0xff
+			if buffer[y*e.pixelWidth+x/PIXEL_SIZE]&(0x80>>(x%PIXEL_SIZE)) != 0 {
+				value = 0x00
+			}
+
+			lx, ly := e.toLogical(x, y)
+			img.Pix[ly*img.Stride+lx] = value
+		}
+	}
+
+	return img
+}
+
+// Remembers the full frame that was sent to the display.
+func (e *Epd) storeBuffer(buffer []byte) {
+	if e.lastBuffer == nil {
+		e.lastBuffer = make([]byte, e.bufferSize)
+	}
+
+	copy(e.lastBuffer, buffer)
+	e.lastHash = 0
+}
+
+// Updates the remembered frame with a byte aligned region, in native coordinates.
+func (e *Epd) storeRegion(buffer []byte, r image.Rectangle) {
+	e.lastHash = 0
+	if e.lastBuffer == nil {
+		return
+	}
+
+	e.copyRegion(e.lastBuffer, buffer, r)
+}
+
+// Writes a byte aligned region into the full frame dst, the opposite of cropBuffer.
+func (e *Epd) copyRegion(dst []byte, buffer []byte, r image.Rectangle) {
+	rowSize := (r.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	for y := 0; y < r.Dy(); y++ {
+		start := (r.Min.Y+y)*e.pixelWidth + r.Min.X/PIXEL_SIZE
+		copy(dst[start:start+rowSize], buffer[y*rowSize:(y+1)*rowSize])
+	}
+}
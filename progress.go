@@ -0,0 +1,114 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Size of the progress bar drawn by DisplayProgress, in pixels.
+const (
+	PROGRESS_BAR_HEIGHT = 40
+	PROGRESS_BAR_BORDER = 2
+)
+
+// Draws a progress bar filled up to fraction, from 0 to 1, with label above it in the
+// middle of the screen, e.g. for firmware updates. The rest of the screen keeps the
+// last frame. After InitPartial and a first frame only the bar and label are sent with
+// a partial refresh, so calling it as the task advances doesn't flash the display.
+// fraction is clamped to [0, 1] and labels wider than the bar are cut with "...".
+func (e *Epd) DisplayProgress(label string, fraction float64) error {
+	if math.IsNaN(fraction) || fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	frame := image.NewGray(e.logicalBounds())
+	r := drawProgress(frame, label, fraction)
+
+	return e.withRecovery(func() error {
+		return e.displayOver(frame, r)
+	})
+}
+
+// Displays the region r of img, in logical coordinates, over the last frame: after
+// InitPartial and a first frame only r is sent with a partial refresh, otherwise the
+// whole frame is refreshed. The pixels of the last frame around r are sent as they
+// are, without being encoded or filtered again.
+func (e *Epd) displayOver(img image.Image, r image.Rectangle) error {
+	if e.mode == modePartial && e.lastBuffer != nil && r != e.logicalBounds() {
+		return e.displayPartial(img, r)
+	}
+
+	buffer := e.whiteBuffer()
+	if e.lastBuffer != nil {
+		copy(buffer, e.lastBuffer)
+	}
+
+	native := e.rectToNative(r.Intersect(e.logicalBounds()))
+	if aligned := e.alignRegion(native); !aligned.Empty() {
+		region := e.getRegionBuffer(img, aligned, e.threshold)
+		e.keepOutside(region, aligned, native)
+		e.copyRegion(buffer, region, aligned)
+	}
+
+	return e.display(buffer)
+}
+
+// Draws the label and progress bar onto img, returning the area they cover.
+func drawProgress(img draw.Image, label string, fraction float64) image.Rectangle {
+	face := basicfont.Face7x13
+	metrics := face.Metrics()
+	bounds := img.Bounds()
+
+	margin := bounds.Dx() / 10
+	bar := image.Rect(bounds.Min.X+margin, 0, bounds.Max.X-margin, PROGRESS_BAR_HEIGHT).
+		Add(image.Pt(0, bounds.Min.Y+(bounds.Dy()-PROGRESS_BAR_HEIGHT)/2))
+	top := bar.Min.Y - metrics.Height.Ceil() - PROGRESS_BAR_BORDER*2
+	area := image.Rect(bar.Min.X, top, bar.Max.X, bar.Max.Y)
+
+	draw.Draw(img, area, image.White, image.Point{}, draw.Src)
+	draw.Draw(img, bar, image.Black, image.Point{}, draw.Src)
+	inner := bar.Inset(PROGRESS_BAR_BORDER)
+	draw.Draw(img, inner, image.White, image.Point{}, draw.Src)
+	filled := inner
+	filled.Max.X = inner.Min.X + int(math.Round(float64(inner.Dx())*fraction))
+	draw.Draw(img, filled, image.Black, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.Black,
+		Face: face,
+	}
+	label = truncateText(drawer, label, fixed.I(bar.Dx()))
+	drawer.Dot = fixed.Point26_6{
+		X: fixed.I(bar.Min.X),
+		Y: fixed.I(top) + metrics.Ascent,
+	}
+	drawer.DrawString(label)
+
+	return area
+}
+
+// Shortens s with "..." until it is at most width wide.
+func truncateText(drawer *font.Drawer, s string, width fixed.Int26_6) string {
+	if drawer.MeasureString(s) <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 && drawer.MeasureString(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + "..."
+}
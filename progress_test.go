@@ -0,0 +1,29 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+func TestDisplayProgressKeepsInvertedFrame(t *testing.T) {
+	e, fake := newTestEpd(t, WithInverted(true))
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	white := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+	if err := e.DisplayImage(white); err != nil {
+		t.Fatal(err)
+	}
+	want := fake.Screen().GrayAt(0, 0)
+
+	for i, fraction := range []float64{0.25, 0.5} {
+		if err := e.DisplayProgress("Updating", fraction); err != nil {
+			t.Fatal(err)
+		}
+		if got := fake.Screen().GrayAt(0, 0); got != want {
+			t.Errorf("update %d changed the rest of the screen to %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+
+	"rsc.io/qr"
+)
+
+// The error correction level of a QR code, from the least to the most tolerant of
+// damage. Higher levels make the code denser for the same data.
+type QRLevel int
+
+const (
+	QRLow QRLevel = iota
+	QRMedium
+	QRQuartile
+	QRHigh
+)
+
+// Default width of the white margin around a QR code, in modules, as required by the
+// QR specification.
+const QR_QUIET_ZONE = 4
+
+// How DisplayQR and QRCode render a QR code. The zero value gives the largest code
+// fitting the screen with QRLow error correction.
+type QROptions struct {
+	// Error correction level, defaults to QRLow.
+	Level QRLevel
+	// Size of each module (black or white square) in pixels. 0 picks the largest size
+	// that fits the screen.
+	ModuleSize int
+	// Width of the white margin around the code in modules. 0 uses QR_QUIET_ZONE, pass
+	// a negative value to leave no margin.
+	QuietZone int
+}
+
+// Displays data as a QR code centered on an otherwise white screen, e.g. a URL or the
+// credentials of a WiFi network. Returns an error if data doesn't fit in a QR code or
+// the code doesn't fit on the screen with the requested module size.
+func (e *Epd) DisplayQR(data string, opts QROptions) error {
+	screen := e.Bounds()
+	if opts.ModuleSize == 0 {
+		opts.ModuleSize = qrModuleSize(data, opts, screen.Size())
+	}
+
+	code, err := QRCode(data, opts)
+	if err != nil {
+		return err
+	}
+
+	size := code.Bounds().Size()
+	if size.X > screen.Dx() || size.Y > screen.Dy() {
+		return fmt.Errorf("QR code of %dx%d pixels does not fit on the screen", size.X, size.Y)
+	}
+
+	return e.DisplayImageAt(code, screen.Size().Sub(size).Div(2))
+}
+
+// Renders data as a QR code, e.g. to draw it onto a Canvas or Composite it with other
+// layers. A ModuleSize of 0 renders one pixel per module.
+func QRCode(data string, opts QROptions) (*image.Gray, error) {
+	if opts.Level < QRLow || opts.Level > QRHigh {
+		return nil, fmt.Errorf("unknown QR error correction level %d", opts.Level)
+	}
+
+	code, err := qr.Encode(data, qr.Level(opts.Level))
+	if err != nil {
+		return nil, fmt.Errorf("encoding QR code: %w", err)
+	}
+
+	module := opts.ModuleSize
+	if module <= 0 {
+		module = 1
+	}
+	quiet := opts.QuietZone
+	if quiet == 0 {
+		quiet = QR_QUIET_ZONE
+	} else if quiet < 0 {
+		quiet = 0
+	}
+
+	side := (code.Size + 2*quiet) * module
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			var value uint8 = 0xff
+			if code.Black(x/module-quiet, y/module-quiet) {
+				value = 0x00
+			}
+			img.Pix[y*img.Stride+x] = value
+		}
+	}
+
+	return img, nil
+}
+
+// Returns the largest module size for which the code of data fits in screen, at
+// least 1 so that encoding errors are still reported by QRCode.
+func qrModuleSize(data string, opts QROptions, screen image.Point) int {
+	code, err := QRCode(data, QROptions{Level: opts.Level, ModuleSize: 1, QuietZone: opts.QuietZone})
+	if err != nil {
+		return 1
+	}
+
+	side := code.Bounds().Dx()
+	min := screen.X
+	if screen.Y < min {
+		min = screen.Y
+	}
+	if side == 0 || side > min {
+		return 1
+	}
+
+	return min / side
+}
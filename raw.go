@@ -0,0 +1,45 @@
+package waveshare7in5v2
+
+// Low level access to the display controller, see Epd.Raw.
+type Raw struct {
+	e *Epd
+}
+
+// Gives direct access to the controller commands, to experiment with registers the
+// driver doesn't support yet. Nothing is validated: a wrong sequence can leave the
+// display unresponsive until Reset and Init are run again.
+func (e *Epd) Raw() *Raw {
+	return &Raw{e: e}
+}
+
+// Sends a single command byte.
+func (r *Raw) SendCommand(cmd byte) error {
+	r.e.mu.Lock()
+	defer r.e.mu.Unlock()
+
+	return r.e.sendCommand(cmd)
+}
+
+// Sends data bytes for the last command.
+func (r *Raw) SendData(data []byte) error {
+	r.e.mu.Lock()
+	defer r.e.mu.Unlock()
+
+	return r.e.sendData(data)
+}
+
+// Sends a command followed by its data.
+func (r *Raw) SendCommandWithData(cmd byte, data []byte) error {
+	r.e.mu.Lock()
+	defer r.e.mu.Unlock()
+
+	return r.e.sendCommandWithData(cmd, data)
+}
+
+// Blocks until the display releases the busy line, e.g. after DISPLAY_REFRESH.
+func (r *Raw) WaitUntilIdle() error {
+	r.e.mu.Lock()
+	defer r.e.mu.Unlock()
+
+	return r.e.waitUntilIdle()
+}
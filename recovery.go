@@ -0,0 +1,33 @@
+package waveshare7in5v2
+
+import "errors"
+
+// Recovers from a display stuck busy: when an update times out waiting for the busy
+// line, the display is reset and initialized again in its current mode and the update
+// is retried once before returning the error, ErrBusyTimeout. Meant for unattended
+// installations.
+func WithBusyRecovery(enabled bool) Option {
+	return func(e *Epd) error {
+		e.busyRecovery = enabled
+		return nil
+	}
+}
+
+// Runs op, retrying it once after a reset and init if it timed out on the busy line
+// and WithBusyRecovery is enabled.
+func (e *Epd) withRecovery(op func() error) error {
+	err := op()
+	if !e.busyRecovery || !errors.Is(err, ErrBusyTimeout) {
+		return err
+	}
+
+	e.warn("Display stuck busy, resetting:", err)
+	// Every init sequence starts with a reset
+	if err := e.reinit(); err != nil {
+		e.warn("Failed to recover display:", err)
+		return err
+	}
+
+	e.warn("Display recovered, retrying")
+	return op()
+}
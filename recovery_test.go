@@ -0,0 +1,95 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+)
+
+// A FakeBackend that can be made to stay busy until the display is reset.
+type stuckBackend struct {
+	*FakeBackend
+	stuck bool
+}
+
+func (b *stuckBackend) Read(pin int) bool {
+	if b.stuck && pin == b.BusyPin {
+		// The busy line is held low while the display is busy
+		return false
+	}
+	return b.FakeBackend.Read(pin)
+}
+
+func (b *stuckBackend) Write(pin int, high bool) {
+	if pin == DEFAULT_RST_PIN && !high {
+		b.stuck = false
+	}
+	b.FakeBackend.Write(pin, high)
+}
+
+func TestUpdatesRecoverFromBusyTimeout(t *testing.T) {
+	white := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+	mono := image.NewPaletted(white.Bounds(), color.Palette{color.White, color.Black})
+	r := image.Rect(0, 0, 16, 16)
+
+	full := func(e *Epd) error { return e.Init() }
+	partial := func(e *Epd) error { return e.InitPartial() }
+	tests := []struct {
+		name   string
+		init   func(e *Epd) error
+		update func(e *Epd) error
+	}{
+		{"DisplayImageFit", full, func(e *Epd) error { return e.DisplayImageFit(white, FitStretch) }},
+		{"DisplayImageDithered", full, func(e *Epd) error { return e.DisplayImageDithered(white) }},
+		{"DisplayImageFast", full, func(e *Epd) error { return e.DisplayImageFast(white) }},
+		{"DisplayImageGray4", func(e *Epd) error { return e.InitGray4() }, func(e *Epd) error { return e.DisplayImageGray4(white) }},
+		{"DisplayImageIfChanged", full, func(e *Epd) error {
+			_, err := e.DisplayImageIfChanged(image.NewGray(white.Bounds()))
+			return err
+		}},
+		{"DisplayImageHashed", full, func(e *Epd) error {
+			_, err := e.DisplayImageHashed(image.NewGray(white.Bounds()))
+			return err
+		}},
+		{"DisplayMono", full, func(e *Epd) error { return e.DisplayMono(mono) }},
+		{"Refresh", full, func(e *Epd) error { return e.Refresh() }},
+		{"DisplayNewPlaneOnly", full, func(e *Epd) error { return e.DisplayNewPlaneOnly(EncodeImage(white, DEFAULT_THRESHOLD)) }},
+		{"DisplayImageDiff", partial, func(e *Epd) error { return e.DisplayImageDiff(image.NewGray(white.Bounds())) }},
+		{"DisplayTile", partial, func(e *Epd) error { return e.DisplayTile(image.NewGray(r), r) }},
+		{"QuickClear", partial, func(e *Epd) error { return e.QuickClear() }},
+		{"ClearRegion", partial, func(e *Epd) error { return e.ClearRegion(r) }},
+		{"InvertRegion", partial, func(e *Epd) error { return e.InvertRegion(r) }},
+		{"Flash", partial, func(e *Epd) error { return e.Flash(r, 1, 0) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := &stuckBackend{FakeBackend: NewFakeBackend()}
+			e, _ := newTestEpd(t,
+				WithBackend(backend),
+				WithBusyRecovery(true),
+				WithBusyTimeout(time.Millisecond),
+				WithBusyPollInterval(time.Millisecond),
+			)
+			if err := test.init(e); err != nil {
+				t.Fatal(err)
+			}
+			if test.name != "DisplayImageGray4" {
+				if err := e.DisplayImage(white); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			backend.stuck = true
+			if err := test.update(e); err != nil {
+				t.Errorf("update wasn't retried after the display was reset: %v", err)
+			}
+			if backend.stuck {
+				t.Error("display wasn't reset")
+			}
+		})
+	}
+}
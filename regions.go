@@ -0,0 +1,105 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// How EncodeImageRegions converts the pixels of a region to black and white.
+type EncodeMode int
+
+const (
+	// Pixels darker than the Threshold of the region become black, crisp for text.
+	EncodeThreshold EncodeMode = iota
+	// Floyd–Steinberg error diffusion over the region, like Dither, for photos.
+	EncodeDither
+	// Ordered dithering with the Matrix of the region, like DisplayImageOrderedDither.
+	EncodeOrderedDither
+)
+
+// A region of the image encoded with its own mode by EncodeImageRegions.
+type EncodeRegion struct {
+	// Area of the image, with its top left corner at 0, 0
+	Rect image.Rectangle
+	Mode EncodeMode
+	// Threshold of EncodeThreshold, 0 for DEFAULT_THRESHOLD
+	Threshold uint8
+	// Matrix of EncodeOrderedDither, nil for Bayer4x4
+	Matrix DitherMatrix
+}
+
+// Same as EncodeImage but encodes each region with its own mode, e.g. a caption
+// thresholded to crisp black over a dithered photo, into a single buffer ready for
+// DisplayBuffer. Pixels outside of every region use threshold, and where regions
+// overlap the last one wins. Error diffusion doesn't cross the edges of its region.
+func EncodeImageRegions(img image.Image, threshold uint8, regions []EncodeRegion) ([]byte, error) {
+	if err := checkImage(img); err != nil {
+		return nil, err
+	}
+
+	e := &Epd{bounds: image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT), background: color.White}
+	img = atOrigin(img)
+	bounds := img.Bounds()
+
+	// Luminance of every pixel, dithered regions are replaced by their black and white
+	// result and thresholded at the middle
+	lum := image.NewGray(bounds)
+	luminanceAt := e.luminanceFunc(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			lum.Pix[lum.PixOffset(x, y)] = luminanceAt(x, y)
+		}
+	}
+	src := image.NewGray(bounds)
+	copy(src.Pix, lum.Pix)
+
+	thresholds := make([]uint8, len(src.Pix))
+	for i := range thresholds {
+		thresholds[i] = threshold
+	}
+
+	for i, region := range regions {
+		r := region.Rect.Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+
+		// Undoes the dithering of the regions before
+		draw.Draw(src, r, lum, r.Min, draw.Src)
+
+		thresholdAt := func(x, y int) uint8 { return 128 }
+		switch region.Mode {
+		case EncodeThreshold:
+			t := region.Threshold
+			if t == 0 {
+				t = DEFAULT_THRESHOLD
+			}
+			thresholdAt = func(x, y int) uint8 { return t }
+		case EncodeDither:
+			draw.Draw(src, r, dither(lum, r, e.background), r.Min, draw.Src)
+		case EncodeOrderedDither:
+			matrix := region.Matrix
+			if matrix == nil {
+				matrix = Bayer4x4
+			}
+			if err := matrix.validate(); err != nil {
+				return nil, fmt.Errorf("region %d: %w", i, err)
+			}
+			thresholdAt = matrix.thresholdAt
+		default:
+			return nil, fmt.Errorf("region %d: unknown encode mode %d", i, region.Mode)
+		}
+
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				thresholds[src.PixOffset(x, y)] = thresholdAt(x, y)
+			}
+		}
+	}
+
+	return e.getRegionBufferFunc(src, e.bounds, func(x, y int) uint8 {
+		return thresholds[src.PixOffset(x, y)]
+	}), nil
+}
@@ -0,0 +1,46 @@
+package waveshare7in5v2
+
+import "fmt"
+
+// Compresses a frame buffer, e.g. from EncodeImage, with run-length encoding to store
+// it in a fraction of its size: e-paper screens are mostly long runs of white (0x00)
+// or black (0xFF) bytes. The format is a sequence of pairs of bytes, a count from 1 to
+// 255 followed by the byte repeated count times. Use DecodeRLE to get the buffer back.
+func EncodeRLE(buf []byte) []byte {
+	var out []byte
+	for i := 0; i < len(buf); {
+		run := 1
+		for i+run < len(buf) && run < 255 && buf[i+run] == buf[i] {
+			run++
+		}
+
+		out = append(out, byte(run), buf[i])
+		i += run
+	}
+
+	return out
+}
+
+// Decompresses a buffer encoded by EncodeRLE, ready to be passed to DisplayBuffer.
+func DecodeRLE(data []byte) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("run-length encoded data has an odd length %d", len(data))
+	}
+
+	size := 0
+	for i := 0; i < len(data); i += 2 {
+		if data[i] == 0 {
+			return nil, fmt.Errorf("run-length encoded data has an empty run at offset %d", i)
+		}
+		size += int(data[i])
+	}
+
+	buf := make([]byte, 0, size)
+	for i := 0; i < len(data); i += 2 {
+		for n := 0; n < int(data[i]); n++ {
+			buf = append(buf, data[i+1])
+		}
+	}
+
+	return buf, nil
+}
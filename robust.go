@@ -0,0 +1,74 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// How much longer the delays of InitRobust are, and how many times it tries to power
+// on the display.
+const (
+	ROBUST_DELAY_FACTOR   = 5
+	ROBUST_POWER_ON_TRIES = 3
+)
+
+// Same as Init but slower and more tolerant, for displays on marginal power supplies.
+// The reset pulse and delays are ROBUST_DELAY_FACTOR times longer and POWER_ON is
+// retried up to ROBUST_POWER_ON_TRIES times, giving the booster time to come up.
+//
+// Use it if Init intermittently fails with ErrPowerOnTimeout or ErrResetFailed, the
+// display stays blank or faint after Init, or the Pi reports under-voltage. Waking up
+// after Sleep keeps using it until Init is called again.
+func (e *Epd) InitRobust() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.robust = true
+	return e.initFullWarm()
+}
+
+// Runs the init sequence init, starting over from the reset up to e.initRetries more
+// times while the display doesn't power on.
+func (e *Epd) retryInit(init func() error) error {
+	if e.recordInit {
+		// Only the last attempt is kept
+		record := init
+		init = func() error {
+			e.initSequence = nil
+			e.recording = true
+			defer func() { e.recording = false }()
+			return record()
+		}
+	}
+
+	err := init()
+	for attempt := 1; errors.Is(err, ErrPowerOnTimeout) && attempt <= e.initRetries; attempt++ {
+		e.warn("Display did not power on, initializing again:", err)
+		err = init()
+	}
+
+	return err
+}
+
+func (e *Epd) robustPowerOn() error {
+	var err error
+	for try := 1; try <= ROBUST_POWER_ON_TRIES; try++ {
+		if err = e.sendCommand(POWER_ON); err != nil {
+			return err
+		}
+		e.wait(100 * time.Millisecond * ROBUST_DELAY_FACTOR)
+
+		if err = e.waitUntilIdle(); err == nil {
+			return nil
+		}
+
+		e.warn("Display did not power on, retrying:", err)
+		if err := e.sendCommand(POWER_OFF); err != nil {
+			return err
+		}
+		e.wait(100 * time.Millisecond * ROBUST_DELAY_FACTOR)
+	}
+
+	return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+}
@@ -0,0 +1,118 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+)
+
+// Rotates the screen by deg degrees clockwise. Only 0, 90, 180 and 270 are supported.
+// With 90 and 270 the screen is in portrait mode and Bounds() becomes 480x800.
+func WithRotation(deg int) Option {
+	return func(e *Epd) error {
+		return e.SetRotation(deg)
+	}
+}
+
+// Rotates the screen by deg degrees clockwise. Only 0, 90, 180 and 270 are supported.
+// Takes effect on the next image sent to the display.
+func (e *Epd) SetRotation(deg int) error {
+	deg = ((deg % 360) + 360) % 360
+	if deg%90 != 0 {
+		return fmt.Errorf("unsupported rotation %d, must be a multiple of 90", deg)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rotation = deg
+	return nil
+}
+
+// Flips the image horizontally, e.g. for panels seen through a mirror or from behind.
+// Mirroring is applied to the physical panel so it is independent from the rotation.
+func WithMirrorX(mirror bool) Option {
+	return func(e *Epd) error {
+		e.mirrorX = mirror
+		return nil
+	}
+}
+
+// Flips the image vertically, see WithMirrorX.
+func WithMirrorY(mirror bool) Option {
+	return func(e *Epd) error {
+		e.mirrorY = mirror
+		return nil
+	}
+}
+
+// Returns the current rotation in degrees.
+func (e *Epd) Rotation() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.rotation
+}
+
+// Maps a point of the rotated screen into the native panel coordinates.
+func (e *Epd) toNative(x, y int) (int, int) {
+	w, h := e.bounds.Dx(), e.bounds.Dy()
+
+	switch e.rotation {
+	case 90:
+		x, y = w-1-y, x
+	case 180:
+		x, y = w-1-x, h-1-y
+	case 270:
+		x, y = y, h-1-x
+	}
+
+	return e.mirror(x, y)
+}
+
+// Maps a point of the native panel into the coordinates of the rotated screen.
+func (e *Epd) toLogical(x, y int) (int, int) {
+	w, h := e.bounds.Dx(), e.bounds.Dy()
+	x, y = e.mirror(x, y)
+
+	switch e.rotation {
+	case 90:
+		return y, w - 1 - x
+	case 180:
+		return w - 1 - x, h - 1 - y
+	case 270:
+		return h - 1 - y, x
+	default:
+		return x, y
+	}
+}
+
+// Flips a point of the native panel according to WithMirrorX and WithMirrorY.
+func (e *Epd) mirror(x, y int) (int, int) {
+	if e.mirrorX {
+		x = e.bounds.Dx() - 1 - x
+	}
+	if e.mirrorY {
+		y = e.bounds.Dy() - 1 - y
+	}
+
+	return x, y
+}
+
+// Maps a rectangle of the rotated screen into the native panel coordinates.
+func (e *Epd) rectToNative(r image.Rectangle) image.Rectangle {
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+
+	x0, y0 := e.toNative(r.Min.X, r.Min.Y)
+	x1, y1 := e.toNative(r.Max.X-1, r.Max.Y-1)
+
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+
+	return image.Rect(x0, y0, x1+1, y1+1)
+}
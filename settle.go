@@ -0,0 +1,37 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"time"
+)
+
+// Typical time the image keeps settling after a refresh, see WaitSettled.
+const SETTLE_TIME = 300 * time.Millisecond
+
+// Blocks until d has elapsed since the end of the last refresh, returning right away if
+// it already has. The busy line is released once the waveform completes, but the ink
+// particles keep moving for a moment: the contrast typically stabilizes within 200 to
+// 500ms, and noticeably later in the cold. Use it before photographing the panel, with
+// SETTLE_TIME as a starting point.
+func (e *Epd) WaitSettled(d time.Duration) {
+	e.mu.Lock()
+	end := e.lastRefreshEnd
+	e.mu.Unlock()
+
+	if remaining := d - time.Since(end); !end.IsZero() && remaining > 0 {
+		e.wait(remaining)
+	}
+}
+
+// Waits d after every refresh before the display methods return, so the image is
+// stable by then, see WaitSettled. Defaults to 0.
+func WithSettleDelay(d time.Duration) Option {
+	return func(e *Epd) error {
+		if d < 0 {
+			return fmt.Errorf("settle delay %v must not be negative", d)
+		}
+
+		e.settleDelay = d
+		return nil
+	}
+}
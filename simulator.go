@@ -0,0 +1,152 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// What a panel driven by the FakeBackend shows, rebuilt from the commands it receives:
+// the data planes, including partial windows, are copied to the screen on each
+// DISPLAY_REFRESH. In black and white only the new plane is shown, once the grayscale
+// waveform is selected the old plane holds the low bit of the 4 gray levels.
+type panelSim struct {
+	width, height int
+	old, plane    []byte
+	screen        []byte
+	// Low bits of the gray levels on screen, nil for a black and white screen
+	screenLow []byte
+	gray      bool
+	partial   bool
+	window    image.Rectangle
+}
+
+// Lazily sizes the planes, by default to the native panel.
+func (s *panelSim) init() {
+	if s.screen != nil {
+		return
+	}
+	if s.width == 0 {
+		s.width, s.height = EPD_WIDTH, EPD_HEIGHT
+	}
+
+	size := (s.width + PIXEL_SIZE - 1) / PIXEL_SIZE * s.height
+	s.old = make([]byte, size)
+	s.plane = make([]byte, size)
+	s.screen = make([]byte, size)
+	s.screenLow = nil
+}
+
+// Updates the state of the panel once all the data of t was received.
+func (s *panelSim) apply(t Transfer) {
+	switch t.Command {
+	case RESOLUTION_SETTING:
+		if len(t.Data) >= 4 {
+			s.width = int(t.Data[0])<<8 | int(t.Data[1])
+			s.height = int(t.Data[2])<<8 | int(t.Data[3])
+			s.screen = nil
+		}
+	case PANEL_SETTING:
+		// Every init sequence sets the panel up before selecting a waveform
+		s.gray = false
+	case FORCE_TEMPERATURE:
+		s.gray = len(t.Data) > 0 && t.Data[0] == 0x5F
+	case PARTIAL_IN:
+		s.partial = true
+	case PARTIAL_OUT:
+		s.partial = false
+	case PARTIAL_WINDOW:
+		if len(t.Data) >= 8 {
+			s.window = image.Rect(
+				int(t.Data[0])<<8|int(t.Data[1]),
+				int(t.Data[4])<<8|int(t.Data[5]),
+				(int(t.Data[2])<<8|int(t.Data[3]))+1,
+				(int(t.Data[6])<<8|int(t.Data[7]))+1,
+			)
+		}
+	case DISPLAY_START_TRANSMISSION_1:
+		s.init()
+		s.load(s.old, t.Data)
+	case DISPLAY_START_TRANSMISSION_2:
+		s.init()
+		s.load(s.plane, t.Data)
+	case DISPLAY_REFRESH:
+		s.init()
+		copy(s.screen, s.plane)
+		s.screenLow = nil
+		if s.gray {
+			s.screenLow = append([]byte(nil), s.old...)
+		}
+	}
+}
+
+// Writes data to plane, into the partial window when one is set.
+func (s *panelSim) load(plane, data []byte) {
+	if !s.partial {
+		copy(plane, data)
+		return
+	}
+
+	rowSize := (s.width + PIXEL_SIZE - 1) / PIXEL_SIZE
+	r := s.window.Intersect(image.Rect(0, 0, s.width, s.height))
+	windowRow := (s.window.Dx() + PIXEL_SIZE - 1) / PIXEL_SIZE
+	for y := r.Min.Y; y < r.Max.Y && len(data) > 0; y++ {
+		n := windowRow
+		if n > len(data) {
+			n = len(data)
+		}
+		start := y*rowSize + r.Min.X/PIXEL_SIZE
+		copy(plane[start:(y+1)*rowSize], data[:n])
+		data = data[n:]
+	}
+}
+
+func (s *panelSim) image() *image.Gray {
+	s.init()
+
+	rowSize := (s.width + PIXEL_SIZE - 1) / PIXEL_SIZE
+	img := image.NewGray(image.Rect(0, 0, s.width, s.height))
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			i, mask := y*rowSize+x/PIXEL_SIZE, byte(0x80>>(x%PIXEL_SIZE))
+			high := s.screen[i]&mask != 0
+
+			if s.screenLow == nil {
+				// Set bits are black
+				if !high {
+					img.SetGray(x, y, color.Gray{Y: 255})
+				}
+				continue
+			}
+
+			// Set bits are lighter, level 0 is black and 3 white
+			var level uint8
+			if high {
+				level = 2
+			}
+			if s.screenLow[i]&mask != 0 {
+				level++
+			}
+			img.SetGray(x, y, color.Gray{Y: level * 85})
+		}
+	}
+
+	return img
+}
+
+// Returns what the simulated panel shows, in its native orientation: the frames the
+// driver refreshed, decoded from the data it sent. Unlike BufferImage it checks the
+// bytes on the wire, so it catches encoding, window and command sequence bugs. The
+// screen is white until the first refresh.
+func (f *FakeBackend) Screen() *image.Gray {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sim.image()
+}
+
+// Writes the screen of the simulated panel as a PNG, see Screen.
+func (f *FakeBackend) SaveScreen(w io.Writer) error {
+	return png.Encode(w, f.Screen())
+}
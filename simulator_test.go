@@ -0,0 +1,46 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestScreenShowsGray4Levels(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.InitGray4(); err != nil {
+		t.Fatal(err)
+	}
+
+	// One stripe per level, from black to white
+	img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	stripe := EPD_WIDTH / 4
+	for level := 0; level < 4; level++ {
+		r := image.Rect(level*stripe, 0, (level+1)*stripe, EPD_HEIGHT)
+		draw.Draw(img, r, image.NewUniform(color.Gray{Y: uint8(level * 85)}), image.Point{}, draw.Src)
+	}
+	if err := e.DisplayImageGray4(img); err != nil {
+		t.Fatal(err)
+	}
+
+	screen := fake.Screen()
+	for level := 0; level < 4; level++ {
+		x := level*stripe + stripe/2
+		if got, want := screen.GrayAt(x, 10).Y, uint8(level*85); got != want {
+			t.Errorf("level %d shows %d, want %d", level, got, want)
+		}
+	}
+
+	// Back in black and white the old plane no longer matters
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	black := image.NewGray(img.Bounds())
+	if err := e.DisplayImage(black); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Screen().GrayAt(EPD_WIDTH-1, 10).Y; got != 0 {
+		t.Errorf("black frame shows %d after grayscale, want 0", got)
+	}
+}
@@ -0,0 +1,52 @@
+package waveshare7in5v2
+
+import "image"
+
+// Sets the image left on the panel by Close, CloseGPIO and Shutdown when enabled with
+// WithSplashOnClose, e.g. a "powered off" graphic. E-paper keeps its image without
+// power, so it replaces whatever was last shown, which might be stale by then.
+// A nil img removes the splash.
+func (e *Epd) SetSplash(img image.Image) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if img == nil {
+		e.splash = nil
+		return nil
+	}
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	// Encoded now so later changes to the options or img don't affect it
+	e.splash = e.getBuffer(img, e.threshold)
+	return nil
+}
+
+// Displays the image set with SetSplash before closing the connection. Nothing is
+// displayed until SetSplash is called, or if the display was never initialized.
+func WithSplashOnClose(enabled bool) Option {
+	return func(e *Epd) error {
+		e.splashOnClose = enabled
+		return nil
+	}
+}
+
+// Shows the splash if enabled, waking the display up if needed.
+func (e *Epd) showSplash() error {
+	if !e.splashOnClose || e.splash == nil || e.mode == modeNone {
+		return nil
+	}
+
+	e.debug("Displaying splash")
+	// A black and white frame needs a black and white waveform
+	if e.mode == modeGray4 || e.mode == modePartial {
+		if err := e.initFull(); err != nil {
+			return err
+		}
+	}
+
+	return e.withRecovery(func() error {
+		return e.display(e.splash)
+	})
+}
@@ -0,0 +1,133 @@
+package waveshare7in5v2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Version of the binary form of DeviceState, the first byte after the magic.
+const STATE_VERSION = 1
+
+var stateMagic = []byte("EPDS")
+
+// What the driver knows about the display, see SaveState. The panel keeps its image
+// without power, so restoring it after a restart lets partial refreshes and
+// DisplayImageIfChanged carry on from the frame on screen without re-rendering it.
+type DeviceState struct {
+	// The mode the display was last initialized with, 0 if it wasn't
+	Mode Mode
+	// Whether the display was asleep
+	Asleep bool
+	// Rotation in degrees, see SetRotation
+	Rotation int
+	// Partial refreshes since the last full one, see WithFullRefreshInterval
+	PartialCount int
+	// The frame on screen in the native orientation with black pixels set, nil if unknown
+	Frame []byte
+}
+
+// Captures the state of the driver, to be serialized with MarshalBinary and given to
+// RestoreState by the next process driving the display.
+func (e *Epd) SaveState() DeviceState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s := DeviceState{
+		Mode:         Mode(e.mode),
+		Asleep:       e.asleep,
+		Rotation:     e.rotation,
+		PartialCount: e.partialCount,
+	}
+	if e.lastBuffer != nil {
+		s.Frame = append([]byte(nil), e.lastBuffer...)
+	}
+
+	return s
+}
+
+// Restores a state captured by SaveState. The registers of the panel can't be trusted
+// to have survived in between, so the display is considered asleep: the next update
+// runs the init sequence of the saved mode again, reloading the frame for partial
+// refresh. Returns ErrBufferSize if the frame doesn't match the display.
+func (e *Epd) RestoreState(s DeviceState) error {
+	if _, ok := modeNames[initMode(s.Mode)]; !ok {
+		return fmt.Errorf("invalid mode %d", int(s.Mode))
+	}
+	if s.Rotation%90 != 0 || s.Rotation < 0 || s.Rotation >= 360 {
+		return fmt.Errorf("unsupported rotation %d, must be 0, 90, 180 or 270", s.Rotation)
+	}
+	if s.PartialCount < 0 {
+		return fmt.Errorf("partial count %d must not be negative", s.PartialCount)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if s.Frame != nil {
+		if err := e.checkBufferSize(s.Frame); err != nil {
+			return err
+		}
+		e.storeBuffer(s.Frame)
+	} else {
+		e.lastBuffer = nil
+		e.lastHash = 0
+	}
+
+	e.mode = initMode(s.Mode)
+	e.asleep = e.mode != modeNone
+	e.poweredOff = false
+	e.rotation = s.Rotation
+	e.partialCount = s.PartialCount
+	return nil
+}
+
+// Encodes the state as the "EPDS" magic, the version, the mode, a flags byte, the
+// rotation, the partial count and the frame length as big endian integers, followed
+// by the frame.
+func (s DeviceState) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, len(stateMagic)+13+len(s.Frame))
+	data = append(data, stateMagic...)
+	data = append(data, STATE_VERSION, byte(s.Mode))
+
+	var flags byte
+	if s.Asleep {
+		flags |= 1
+	}
+	data = append(data, flags)
+
+	data = binary.BigEndian.AppendUint16(data, uint16(s.Rotation))
+	data = binary.BigEndian.AppendUint32(data, uint32(s.PartialCount))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(s.Frame)))
+	return append(data, s.Frame...), nil
+}
+
+// Decodes a state encoded by MarshalBinary.
+func (s *DeviceState) UnmarshalBinary(data []byte) error {
+	header := len(stateMagic) + 13
+	if len(data) < header || string(data[:len(stateMagic)]) != string(stateMagic) {
+		return errors.New("not a device state")
+	}
+
+	data = data[len(stateMagic):]
+	if data[0] != STATE_VERSION {
+		return fmt.Errorf("unsupported device state version %d", data[0])
+	}
+
+	frameSize := binary.BigEndian.Uint32(data[9:])
+	if uint32(len(data)-13) != frameSize {
+		return fmt.Errorf("device state frame is %d bytes, want %d", len(data)-13, frameSize)
+	}
+
+	*s = DeviceState{
+		Mode:         Mode(data[1]),
+		Asleep:       data[2]&1 != 0,
+		Rotation:     int(binary.BigEndian.Uint16(data[3:])),
+		PartialCount: int(binary.BigEndian.Uint32(data[5:])),
+	}
+	if frameSize > 0 {
+		s.Frame = append([]byte(nil), data[13:]...)
+	}
+
+	return nil
+}
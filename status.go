@@ -0,0 +1,34 @@
+package waveshare7in5v2
+
+// Reads the status flags of the controller (GET_STATUS), e.g. to check that a panel is
+// connected and responding rather than silently ignoring every write. The bits are, from
+// the lowest: busy released (1 when idle), power off done, power on done, data received,
+// I2C master busy released and I2C error.
+//
+// Requires the display data line to be wired to MISO, which the waveshare HAT doesn't
+// do, and WithMISO for the default backend. With no panel connected nothing drives the
+// line and the status reads as 0x00 or 0xFF, while a wedged panel keeps reporting busy.
+// ErrReadNotSupported is returned if the Backend can't read, see Receiver.
+func (e *Epd) ReadStatus() (byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkReadable(); err != nil {
+		return 0, err
+	}
+
+	if err := e.wake(); err != nil {
+		return 0, err
+	}
+
+	if err := e.sendCommand(GET_STATUS); err != nil {
+		return 0, err
+	}
+
+	data, err := e.receiveData(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return data[0], nil
+}
@@ -0,0 +1,43 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Hides the Receiver implementation of the backend it wraps.
+type writeOnlyBackend struct {
+	Backend
+}
+
+func TestReadStatus(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ScriptRead(0x01)
+	status, err := e.ReadStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 0x01 {
+		t.Errorf("status 0x%02x, want 0x01", status)
+	}
+	if got := fake.Commands(); got[len(got)-1] != GET_STATUS {
+		t.Errorf("last command 0x%02x, want GET_STATUS", got[len(got)-1])
+	}
+}
+
+func TestReadStatusWithoutReceiver(t *testing.T) {
+	fake := NewFakeBackend()
+	e, err := New(WithBackend(writeOnlyBackend{fake}), WithSleepFunc(func(time.Duration) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.ReadStatus(); !errors.Is(err, ErrReadNotSupported) {
+		t.Errorf("got %v, want ErrReadNotSupported", err)
+	}
+}
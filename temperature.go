@@ -0,0 +1,36 @@
+package waveshare7in5v2
+
+// Reads the internal temperature sensor of the display, in degrees Celsius.
+//
+// The controller already uses this sensor to pick the built-in waveform on every refresh,
+// the reading lets installations in cold enclosures decide whether to refresh less often
+// or use Init over InitFast. Requires the display data line wired to MISO, and WithMISO
+// for the default backend, otherwise ErrReadNotSupported is returned like ReadStatus.
+func (e *Epd) ReadTemperature() (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkReadable(); err != nil {
+		return 0, err
+	}
+
+	if err := e.wake(); err != nil {
+		return 0, err
+	}
+
+	if err := e.sendCommand(TEMPERATURE_CALIBRATION); err != nil {
+		return 0, err
+	}
+	if err := e.waitUntilIdle(); err != nil {
+		return 0, err
+	}
+
+	data, err := e.receiveData(2)
+	if err != nil {
+		return 0, err
+	}
+
+	// 11 bit two's complement value, MSB first, 0.125°C per step
+	raw := int16(uint16(data[0])<<8|uint16(data[1])) >> 5
+	return float64(raw) / 8, nil
+}
@@ -0,0 +1,35 @@
+package waveshare7in5v2
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadTemperature(t *testing.T) {
+	e, fake := newTestEpd(t)
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 25.5°C is 204 steps of 0.125°C, left aligned in 11 bits
+	fake.ScriptRead(204>>3, 204<<5&0xFF)
+	temp, err := e.ReadTemperature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if temp != 25.5 {
+		t.Errorf("temperature %v, want 25.5", temp)
+	}
+}
+
+func TestReadTemperatureWithoutReceiver(t *testing.T) {
+	e, err := New(WithBackend(writeOnlyBackend{NewFakeBackend()}), WithSleepFunc(func(time.Duration) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.ReadTemperature(); !errors.Is(err, ErrReadNotSupported) {
+		t.Errorf("got %v, want ErrReadNotSupported", err)
+	}
+}
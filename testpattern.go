@@ -0,0 +1,100 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// A built in image for bringing up a panel and spotting defects, see DisplayTestPattern.
+type TestPattern int
+
+const (
+	// Alternating black and white squares of TEST_PATTERN_CELL pixels, shows the
+	// wiring works and whether the image is shifted or mirrored.
+	PatternCheckerboard TestPattern = iota
+	// GRADIENT_BANDS vertical bands from black to white, dithered in black and white
+	// modes and in 4 levels after InitGray4.
+	PatternGradient
+	// One pixel wide lines every TEST_PATTERN_CELL pixels and along the edges, shows
+	// dead gate or source lines.
+	PatternGrid
+	// Every pixel black, shows dead pixels.
+	PatternBlack
+	// Every pixel white, shows stuck pixels.
+	PatternWhite
+)
+
+// Geometry of the test patterns, in pixels and bands.
+const (
+	TEST_PATTERN_CELL = 16
+	GRADIENT_BANDS    = 8
+)
+
+// Displays pattern over the whole screen, to confirm the wiring and spot dead pixels or
+// lines without preparing an image.
+func (e *Epd) DisplayTestPattern(pattern TestPattern) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	img, err := testPatternImage(pattern, e.logicalBounds())
+	if err != nil {
+		return err
+	}
+
+	e.debug("Displaying test pattern", pattern)
+	return e.withRecovery(func() error {
+		switch {
+		case e.mode == modeGray4:
+			return e.displayImageGray4(img)
+		case pattern == PatternGradient:
+			return e.displayImageDithered(img)
+		default:
+			return e.displayImageThreshold(img, e.threshold)
+		}
+	})
+}
+
+// Renders pattern onto an image covering r.
+func testPatternImage(pattern TestPattern, r image.Rectangle) (*image.Gray, error) {
+	img := image.NewGray(r)
+	black, white := color.Gray{}, color.Gray{Y: 0xff}
+
+	switch pattern {
+	case PatternCheckerboard:
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				c := white
+				if ((x-r.Min.X)/TEST_PATTERN_CELL+(y-r.Min.Y)/TEST_PATTERN_CELL)%2 == 0 {
+					c = black
+				}
+				img.SetGray(x, y, c)
+			}
+		}
+	case PatternGradient:
+		for i := 0; i < GRADIENT_BANDS; i++ {
+			band := image.Rect(r.Min.X+i*r.Dx()/GRADIENT_BANDS, r.Min.Y, r.Min.X+(i+1)*r.Dx()/GRADIENT_BANDS, r.Max.Y)
+			level := color.Gray{Y: uint8(i * 255 / (GRADIENT_BANDS - 1))}
+			draw.Draw(img, band, image.NewUniform(level), image.Point{}, draw.Src)
+		}
+	case PatternGrid:
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				c := white
+				if (x-r.Min.X)%TEST_PATTERN_CELL == 0 || (y-r.Min.Y)%TEST_PATTERN_CELL == 0 || x == r.Max.X-1 || y == r.Max.Y-1 {
+					c = black
+				}
+				img.SetGray(x, y, c)
+			}
+		}
+	case PatternBlack:
+		draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+	case PatternWhite:
+		draw.Draw(img, r, image.White, image.Point{}, draw.Src)
+	default:
+		return nil, fmt.Errorf("unknown test pattern %d", pattern)
+	}
+
+	return img, nil
+}
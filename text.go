@@ -0,0 +1,102 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Horizontal alignment of text relative to the point given to DrawText.
+type TextAlign int
+
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// Draws s in black onto the canvas using face, e.g. basicfont.Face7x13.
+// pt is the baseline of the first line, each "\n" starts a new line below it.
+// Lines are aligned horizontally to pt.X according to align.
+func (c *Canvas) DrawText(face font.Face, s string, pt image.Point, align TextAlign) {
+	drawer := &font.Drawer{
+		Dst:  c,
+		Src:  image.Black,
+		Face: face,
+	}
+
+	lineHeight := face.Metrics().Height
+	y := fixed.I(pt.Y)
+	for _, line := range strings.Split(s, "\n") {
+		x := fixed.I(pt.X)
+		switch align {
+		case AlignCenter:
+			x -= drawer.MeasureString(line) / 2
+		case AlignRight:
+			x -= drawer.MeasureString(line)
+		}
+
+		drawer.Dot = fixed.Point26_6{X: x, Y: y}
+		drawer.DrawString(line)
+		y += lineHeight
+	}
+}
+
+// Columns between tab stops in RenderMonospaceBlock.
+const TAB_WIDTH = 8
+
+// Renders lines of monospace text, e.g. the output of a command, black on white onto an
+// image of the size of the panel in its native orientation, ready for DisplayImage.
+// Tabs are expanded with spaces to the next multiple of TAB_WIDTH columns. Lines too
+// wide for the panel are clipped, and those below its bottom edge dropped.
+func RenderMonospaceBlock(lines []string, face font.Face) image.Image {
+	img := image.NewGray(image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.Black,
+		Face: face,
+	}
+
+	metrics := face.Metrics()
+	y := metrics.Ascent
+	for _, line := range lines {
+		if y-metrics.Ascent+metrics.Height > fixed.I(EPD_HEIGHT) {
+			break
+		}
+
+		// The drawer clips whatever falls outside of the image
+		drawer.Dot = fixed.Point26_6{Y: y}
+		drawer.DrawString(expandTabs(line))
+		y += metrics.Height
+	}
+
+	return img
+}
+
+// Replaces the tabs of line with spaces up to the next tab stop.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var b strings.Builder
+	column := 0
+	for _, r := range line {
+		if r != '\t' {
+			b.WriteRune(r)
+			column++
+			continue
+		}
+
+		spaces := TAB_WIDTH - column%TAB_WIDTH
+		b.WriteString(strings.Repeat(" ", spaces))
+		column += spaces
+	}
+
+	return b.String()
+}
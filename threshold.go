@@ -0,0 +1,154 @@
+package waveshare7in5v2
+
+import (
+	"image"
+	"image/color"
+)
+
+// Same as DisplayImage but picks the threshold from the luminance histogram of the
+// image using Otsu's method, so images with very different exposure each get a
+// suitable cutoff. Returns the threshold that was used, e.g. to pass it to
+// WithThreshold once a good one was found.
+func (e *Epd) DisplayImageAutoThreshold(img image.Image) (uint8, error) {
+	if err := checkImage(img); err != nil {
+		return 0, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	threshold := e.otsuThreshold(atOrigin(img))
+	e.debug("Computed threshold:", threshold)
+
+	err := e.withRecovery(func() error {
+		return e.displayImageThreshold(img, threshold)
+	})
+	return threshold, err
+}
+
+// Returns the threshold Otsu's method picks for img, which best separates its pixels
+// into a dark and a light class. Pixels with a luminance below it are displayed
+// as black, so it can be passed to DisplayImageThreshold or PreviewThresholds. Only
+// the part of img that fits on the panel in its native orientation is considered.
+func OtsuThreshold(img image.Image) uint8 {
+	e := &Epd{bounds: image.Rect(0, 0, EPD_WIDTH, EPD_HEIGHT), background: color.White}
+	return e.otsuThreshold(img)
+}
+
+func (e *Epd) otsuThreshold(img image.Image) uint8 {
+	var histogram [256]int
+	luminanceAt := e.luminanceFunc(img)
+	// Only the pixels that end up on screen count
+	r := img.Bounds().Intersect(e.logicalBounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			histogram[luminanceAt(x, y)]++
+		}
+	}
+
+	total := r.Dx() * r.Dy()
+	sum := 0
+	for i, n := range histogram {
+		sum += i * n
+	}
+
+	// Class 0 holds the luminances up to k, pick the k maximizing the variance
+	// between both classes
+	var best float64
+	bestK := -1
+	count0, sum0 := 0, 0
+	for k, n := range histogram {
+		count0 += n
+		sum0 += k * n
+		count1 := total - count0
+		if count0 == 0 {
+			continue
+		}
+		if count1 == 0 {
+			break
+		}
+
+		mean0 := float64(sum0) / float64(count0)
+		mean1 := float64(sum-sum0) / float64(count1)
+		variance := float64(count0) * float64(count1) * (mean0 - mean1) * (mean0 - mean1)
+		if variance > best {
+			best = variance
+			bestK = k
+		}
+	}
+
+	// Images with a single luminance can't be split, keep the default
+	if bestK < 0 {
+		return DEFAULT_THRESHOLD
+	}
+
+	// Everything up to k is black
+	return uint8(bestK + 1)
+}
+
+// Share of the darkest and lightest pixels AutoContrast ignores, so a few stray pixels
+// don't prevent the stretch.
+const AUTO_CONTRAST_CLIP = 0.005
+
+// Same as DisplayImage but stretches the contrast of img first with AutoContrast, which
+// rescues faint scans and washed-out photos that would otherwise threshold to a blank
+// or solid screen.
+func (e *Epd) DisplayImageAutoContrast(img image.Image) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stretched := e.autoContrast(img)
+	return e.withRecovery(func() error {
+		return e.displayImageThreshold(stretched, e.threshold)
+	})
+}
+
+// Returns the luminance of img linearly stretched to the full range: the darkest pixels
+// become black and the lightest white, ignoring AUTO_CONTRAST_CLIP of them at both ends.
+// Images of a single luminance are returned as they are, in grayscale. The result can
+// be thresholded or dithered like any other image.
+func AutoContrast(img image.Image) *image.Gray {
+	e := &Epd{background: color.White}
+	return e.autoContrast(img)
+}
+
+func (e *Epd) autoContrast(img image.Image) *image.Gray {
+	r := img.Bounds()
+	out := image.NewGray(r)
+	luminanceAt := e.luminanceFunc(img)
+
+	var histogram [256]int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			l := luminanceAt(x, y)
+			out.Pix[out.PixOffset(x, y)] = l
+			histogram[l]++
+		}
+	}
+
+	clip := int(float64(r.Dx()*r.Dy()) * AUTO_CONTRAST_CLIP)
+	lo, hi := 0, 255
+	for count := histogram[lo]; count <= clip && lo < 255; count += histogram[lo] {
+		lo++
+	}
+	for count := histogram[hi]; count <= clip && hi > 0; count += histogram[hi] {
+		hi--
+	}
+	if hi <= lo {
+		return out
+	}
+
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = uint8(clamp((i-lo)*255/(hi-lo), 0, 255))
+	}
+	for i, l := range out.Pix {
+		out.Pix[i] = lut[l]
+	}
+
+	return out
+}
@@ -0,0 +1,44 @@
+package waveshare7in5v2
+
+import (
+	"context"
+	"errors"
+	"image"
+)
+
+// Draws img into the dst rectangle of the screen with a partial refresh, the top left
+// corner of img landing on dst.Min whatever its bounds are, so tiles sliced out of a
+// larger image with SubImage can be pushed as is. img is clipped to dst and dst to
+// Bounds(). Pixels around dst that are sent to keep the region byte aligned are left as
+// they were. Requires InitPartial to be called first, like DisplayPartial.
+func (e *Epd) DisplayTile(img image.Image, dst image.Rectangle) error {
+	if err := checkImage(img); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	dst = dst.Intersect(e.logicalBounds())
+	if dst.Empty() {
+		return errors.New("tile is outside of the display bounds")
+	}
+
+	e.debug("Displaying tile")
+	native := e.rectToNative(dst)
+	r := e.alignRegion(native)
+
+	src := img.Bounds()
+	tile := &translatedImage{img, src.Min.Sub(dst.Min)}
+	buffer := e.getRegionBuffer(tile, r, e.threshold)
+	e.keepOutside(buffer, r, native)
+
+	if err := e.withRecovery(func() error {
+		return e.sendPartial(context.Background(), buffer, r)
+	}); err != nil {
+		return err
+	}
+
+	e.debug("Tile displayed")
+	return nil
+}
@@ -0,0 +1,90 @@
+package waveshare7in5v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Checks every interval that the display isn't stuck busy, until ctx is done. A display
+// busy for longer than the busy timeout while no update is running is wedged: the
+// watchdog warns, resets and initializes it again in its current mode, then calls the
+// handler set by WithWatchdogHandler. Checks are skipped while the display sleeps or
+// before it was initialized. The status register isn't read, most wirings can't.
+// Returns an error if interval isn't positive or a watchdog is already running.
+func (e *Epd) StartWatchdog(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("watchdog interval %v must be positive", interval)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.watchdog {
+		return errors.New("watchdog already running")
+	}
+	e.watchdog = true
+
+	go e.runWatchdog(ctx, interval)
+	return nil
+}
+
+// Calls fn each time the watchdog recovered a wedged display, with the error that
+// revealed it and the error of the recovery, nil if the display was initialized again.
+// fn runs on the goroutine of the watchdog and may update the display, e.g. to redraw
+// the last frame after a failed recovery.
+func WithWatchdogHandler(fn func(cause, recoverErr error)) Option {
+	return func(e *Epd) error {
+		e.onWedged = fn
+		return nil
+	}
+}
+
+func (e *Epd) runWatchdog(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.mu.Lock()
+			e.watchdog = false
+			e.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+
+		e.mu.Lock()
+		cause, recoverErr := e.checkResponsive()
+		handler := e.onWedged
+		e.mu.Unlock()
+
+		if cause != nil && handler != nil {
+			handler(cause, recoverErr)
+		}
+	}
+}
+
+// Waits for a busy display to become idle and recovers it if it doesn't, returning the
+// timeout and the error of the recovery. Returns nil errors if the display responded.
+func (e *Epd) checkResponsive() (error, error) {
+	if !e.initialized() || !e.isBusy() {
+		return nil, nil
+	}
+
+	cause := e.waitUntilIdle()
+	if cause == nil {
+		return nil, nil
+	}
+
+	e.warn("Watchdog found the display stuck busy, resetting:", cause)
+	// Every init sequence starts with a reset
+	if err := e.reinit(); err != nil {
+		e.warn("Watchdog failed to recover display:", err)
+		return cause, err
+	}
+
+	e.warn("Watchdog recovered display")
+	return cause, nil
+}
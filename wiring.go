@@ -0,0 +1,71 @@
+package waveshare7in5v2
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long VerifyWiring watches the busy line for the display to start powering on.
+const WIRING_BUSY_WINDOW = 500 * time.Millisecond
+
+// Checks that a display answers on the configured pins, turning the silent failures of
+// a misconfigured pin into an error naming what to check. It resets the display, which
+// must then release the busy line, and sends POWER_ON, which must make it hold the busy
+// line while the booster comes up, then POWER_OFF.
+//
+// It is a heuristic: it can't tell which of DC, CS or the SPI lines is wrong, and a busy
+// pin pulled to the idle level reads the same as no display at all. The display loses
+// its register setup, Init must be called afterwards.
+func (e *Epd) VerifyWiring() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.verifyWiring()
+}
+
+func (e *Epd) verifyWiring() error {
+	e.debug("Verifying wiring")
+
+	// Like Reset the registers are back to their defaults, whatever happens next
+	e.mode = modeNone
+	e.asleep = false
+	e.poweredOff = false
+
+	if err := e.reset(); err != nil {
+		return fmt.Errorf("%w, check the BUSY pin (GPIO %d), the RST pin (GPIO %d) and the power supply", err, e.busy, e.rst)
+	}
+
+	if err := e.sendCommand(POWER_ON); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(WIRING_BUSY_WINDOW)
+	for !e.isBusy() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: the busy line (GPIO %d) didn't react to POWER_ON, check the DC (GPIO %d), CS and SPI wiring", ErrNoDisplay, e.busy, e.dc)
+		}
+		e.wait(time.Millisecond)
+	}
+
+	if err := e.waitUntilIdle(); err != nil {
+		return fmt.Errorf("%w: %v", ErrPowerOnTimeout, err)
+	}
+	if err := e.sendCommand(POWER_OFF); err != nil {
+		return err
+	}
+	if err := e.waitUntilIdle(); err != nil {
+		return err
+	}
+
+	e.debug("Wiring verified")
+	return nil
+}
+
+// Runs VerifyWiring when creating the driver, failing New if no display answers.
+// Defaults to false since it takes a bit of time and cycles the panel power.
+func WithWiringCheck(enabled bool) Option {
+	return func(e *Epd) error {
+		e.wiringCheck = enabled
+		return nil
+	}
+}